@@ -29,6 +29,12 @@ func main() {
 	apiKey := os.Getenv("OPENROUTER_API_KEY")
 	model := os.Getenv("CV_AI_MODEL")
 
+	// Languages the candidate speaks, e.g. CV_LANGUAGES=en:native,ja:conversational
+	languages, err := models.ParseLanguageConstraints(os.Getenv("CV_LANGUAGES"))
+	if err != nil {
+		log.Fatalf("Failed to parse CV_LANGUAGES: %v", err)
+	}
+
 	err = db.Ping()
 	if err != nil {
 		log.Fatal("Error pinging db")
@@ -53,23 +59,44 @@ func main() {
 		log.Fatalf("Failed to get cv: %v", err)
 	}
 
-	job, err := jobRepo.GetJobByID(4306471753)
+	job, err := jobRepo.GetJobByID(models.SourceLinkedIn, 4306471753)
 
 	if err != nil {
 		log.Fatalf("Failed to get job: %v", err)
 	}
 
-	jobDescription, jobCriteria, err := jobDescriptionRepo.GetJobDescriptionByJobID(job.ID)
+	jobDescription, jobCriteria, err := jobDescriptionRepo.GetJobDescriptionByJobID(job.Source, job.ID)
 
 	if err != nil {
 		log.Fatalf("Failed to get job description: %v", err)
 	}
 
-	result, err := openRouterService.AnalyzeJobDescription(string(cv), models.JobDescription{
+	jd := models.JobDescription{
 		JobID:       job.ID,
+		Source:      job.Source,
 		Description: jobDescription,
 		Criteria:    jobCriteria,
-	})
+	}
+
+	// Skip jobs asking for languages the candidate does not speak without spending an AI call.
+	// Without CV_LANGUAGES, or for jobs stored before languages were detected, the AI checks them.
+	if len(languages) > 0 {
+		jd.Language, jd.LanguageRequirements, err = jobDescriptionRepo.GetLanguageRequirements(job.Source, job.ID)
+		if err != nil {
+			log.Fatalf("Failed to get language requirements: %v", err)
+		}
+		if jd.LanguageRequirements == nil {
+			log.Printf("Languages of job %d were not detected yet, leaving them to the AI analysis", job.ID)
+		} else {
+			if err := languages.Check(jd); err != nil {
+				log.Printf("Skipping job %d: %v", job.ID, err)
+				return
+			}
+			openRouterService.SetLanguagesChecked(true)
+		}
+	}
+
+	result, err := openRouterService.AnalyzeJobDescription(string(cv), jd)
 
 	if err != nil {
 		log.Fatalf("Failed to get job analysis result: %v", err)
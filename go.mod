@@ -6,17 +6,17 @@ toolchain go1.24.7
 
 require (
 	github.com/PuerkitoBio/goquery v1.10.3
+	github.com/andybalholm/cascadia v1.3.3
 	github.com/eduardolat/openroutergo v0.1.0
 	github.com/golang-migrate/migrate/v4 v4.19.0
 	github.com/joho/godotenv v1.5.1
 	github.com/lib/pq v1.10.9
+	golang.org/x/net v0.44.0
 	golang.org/x/time v0.5.0
 )
 
 require (
-	github.com/andybalholm/cascadia v1.3.3 // indirect
 	github.com/hashicorp/errwrap v1.1.0 // indirect
 	github.com/hashicorp/go-multierror v1.1.1 // indirect
 	github.com/orsinium-labs/enum v1.4.0 // indirect
-	golang.org/x/net v0.44.0 // indirect
 )
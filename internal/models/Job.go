@@ -1,17 +1,61 @@
 package models
 
+import "time"
+
+// Job sources stored in the jobs.source column
+const (
+	SourceLinkedIn   = "linkedin"
+	SourceGreenhouse = "greenhouse"
+	SourceLever      = "lever"
+	SourceAshby      = "ashby"
+	SourceFeed       = "feed"
+)
+
+// Workplace types stored in the jobs.workplace_type column
+const (
+	WorkplaceOnsite = "onsite"
+	WorkplaceHybrid = "hybrid"
+	WorkplaceRemote = "remote"
+)
+
+// Salary periods stored in the jobs.salary_period column
+const (
+	SalaryPeriodHour  = "hour"
+	SalaryPeriodDay   = "day"
+	SalaryPeriodWeek  = "week"
+	SalaryPeriodMonth = "month"
+	SalaryPeriodYear  = "year"
+)
+
 type Job struct {
 	ID          int64
+	Source      string // Job board the posting came from, together with ID it identifies the job
 	Title       string
 	Company     string
 	CompanyLink string
 	Location    string
 	JobLink     string
-}
 
-type SearchQuery struct {
-	Keywords string `json:"keywords"`
-	Location string `json:"location"`
-	FWT      string `json:"f_WT"`  // Work type filter (1=onsite, 2=remote, 3=hybrid)
-	GeoId    string `json:"geoId"` // Geographic location ID
+	WorkplaceType  string  // One of the Workplace* constants, empty when unknown
+	Remote         bool    // Whether the posting can be done fully remote
+	WorkTypeFilter string  // LinkedIn f_WT filter of the search that listed the job, e.g. "2,3"
+	CountryCode    string  // ISO 3166-1 alpha-2 code resolved from Location, empty when unknown
+	Region         string  // State or prefecture resolved from Location
+	City           string  // City or ward resolved from Location
+	SalaryMin      float64 // Lower bound of the advertised salary, 0 when unknown
+	SalaryMax      float64 // Upper bound of the advertised salary, 0 when unknown
+	SalaryCurrency string  // ISO 4217 currency code of the salary
+	SalaryPeriod   string  // One of the SalaryPeriod* constants
+
+	SalaryAnnualMin      float64 // Salary range converted to a full year, 0 when unknown
+	SalaryAnnualMax      float64
+	SalaryAnnualCurrency string // Base currency of the exchange rates, or SalaryCurrency when it has no rate
+
+	PostedAt      *time.Time // When the posting was listed, in UTC, nil when unknown
+	SalaryInsight string     // Salary as shown on the search card, e.g. "$120,000.00 - $150,000.00"
+	Badges        []string   // Card badges such as "Actively Hiring" or benefits
+	CompanyLogo   string     // URL of the company logo
+	URN           string     // Source entity URN, e.g. urn:li:jobPosting:4306471753
+
+	ClosedAt *time.Time // When the posting was found to no longer accept applications, nil while open
 }
@@ -1,7 +1,44 @@
 package models
 
+// Seniority levels stored in the job_descriptions.seniority column
+const (
+	SeniorityInternship    = "internship"
+	SeniorityEntryLevel    = "entry_level"
+	SeniorityAssociate     = "associate"
+	SeniorityMidSenior     = "mid_senior"
+	SeniorityDirector      = "director"
+	SeniorityExecutive     = "executive"
+	SeniorityNotApplicable = "not_applicable"
+)
+
+// Employment types stored in the job_descriptions.employment_type column
+const (
+	EmploymentFullTime   = "full_time"
+	EmploymentPartTime   = "part_time"
+	EmploymentContract   = "contract"
+	EmploymentTemporary  = "temporary"
+	EmploymentInternship = "internship"
+	EmploymentVolunteer  = "volunteer"
+	EmploymentOther      = "other"
+)
+
 type JobDescription struct {
 	JobID       int64
-	Description string
-	Criteria    map[string]string
+	Source      string
+	Description string            // Plain text, used in prompts
+	Criteria    map[string]string // Criteria as shown by the source, keyed by their localized labels
+
+	DescriptionMarkdown string // The description with its lists, links, headings and tables as Markdown
+	DescriptionHTML     string // The description markup with only formatting elements and safe links
+
+	Seniority      string   // One of the Seniority* constants, empty when unknown
+	EmploymentType string   // One of the Employment* constants, empty when unknown
+	JobFunctions   []string // English names, e.g. "Engineering", "Information Technology"
+	Industries     []string // English names, e.g. "Software Development"
+
+	Language             string                // ISO 639-1 code of the language the description is written in
+	LanguageRequirements []LanguageRequirement // Spoken languages the posting asks for
+
+	Closed         bool // The posting no longer accepts applications
+	ApplicantCount int  // Number of applicants shown on the posting, 0 when not shown
 }
@@ -0,0 +1,91 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Language proficiency levels stored in job_descriptions.language_requirements, from the lowest
+const (
+	LanguageLevelBasic          = "basic"
+	LanguageLevelConversational = "conversational"
+	LanguageLevelBusiness       = "business"
+	LanguageLevelFluent         = "fluent"
+	LanguageLevelNative         = "native"
+)
+
+var languageLevelRanks = map[string]int{
+	LanguageLevelBasic:          1,
+	LanguageLevelConversational: 2,
+	LanguageLevelBusiness:       3,
+	LanguageLevelFluent:         4,
+	LanguageLevelNative:         5,
+}
+
+// LanguageLevelRank orders levels from 1 for basic to 5 for native, 0 for unknown levels
+func LanguageLevelRank(level string) int {
+	return languageLevelRanks[level]
+}
+
+// LanguageRequirement is a spoken language a posting asks for
+type LanguageRequirement struct {
+	Language string `json:"language"`        // ISO 639-1 code, e.g. "ja"
+	Level    string `json:"level,omitempty"` // One of the LanguageLevel* constants, empty when not stated
+	Required bool   `json:"required"`        // False when the language is only preferred
+	Evidence string `json:"evidence"`        // Phrase the requirement was found in, e.g. "JLPT N2"
+}
+
+// LanguageConstraints are the languages a candidate speaks, keyed by ISO 639-1 code, with their level
+type LanguageConstraints map[string]string
+
+// ParseLanguageConstraints reads constraints such as "en:native,ja:conversational"
+func ParseLanguageConstraints(s string) (LanguageConstraints, error) {
+	constraints := make(LanguageConstraints)
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		language, level, ok := strings.Cut(part, ":")
+		language, level = strings.ToLower(strings.TrimSpace(language)), strings.ToLower(strings.TrimSpace(level))
+		if !ok || language == "" || LanguageLevelRank(level) == 0 {
+			return nil, fmt.Errorf("invalid language constraint %q, expected language:level with a level of basic, conversational, business, fluent or native", part)
+		}
+		constraints[language] = level
+	}
+	return constraints, nil
+}
+
+// Check returns an error naming every requirement of jd the candidate does not meet. A description
+// written in a language the candidate does not speak fails as well, and a required language
+// without a stated level is taken to need business level.
+func (c LanguageConstraints) Check(jd JobDescription) error {
+	var unmet []string
+
+	if jd.Language != "" {
+		if _, ok := c[jd.Language]; !ok {
+			unmet = append(unmet, "description in "+jd.Language)
+		}
+	}
+
+	for _, requirement := range jd.LanguageRequirements {
+		if !requirement.Required {
+			continue
+		}
+		level := requirement.Level
+		if level == "" {
+			level = LanguageLevelBusiness
+		}
+		if LanguageLevelRank(c[requirement.Language]) < LanguageLevelRank(level) {
+			unmet = append(unmet, fmt.Sprintf("%s %s (%q)", level, requirement.Language, requirement.Evidence))
+		}
+	}
+
+	if len(unmet) > 0 {
+		sort.Strings(unmet)
+		return fmt.Errorf("language requirements not met: %s", strings.Join(unmet, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// Scrape run statuses stored in the scrape_runs.status column
+const (
+	RunStatusRunning    = "running"
+	RunStatusCompleted  = "completed"
+	RunStatusFailed     = "failed"
+	RunStatusIncomplete = "incomplete" // Finished with listed jobs left unsaved, can be resumed
+)
+
+// ScrapeRun is one execution of a search profile, kept so it can be resumed
+type ScrapeRun struct {
+	ID        int64
+	Status    string
+	Profile   SearchProfile
+	StartedAt time.Time
+}
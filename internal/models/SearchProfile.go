@@ -0,0 +1,8 @@
+package models
+
+// SearchProfile is a named set of searches run together by the pipeline
+type SearchProfile struct {
+	Name    string        `json:"name"`
+	Pages   int           `json:"pages"` // Result pages fetched per query and source
+	Queries []SearchQuery `json:"queries"`
+}
@@ -0,0 +1,143 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ExperienceLevel is a LinkedIn experience level filter value (f_E)
+type ExperienceLevel int
+
+const (
+	ExperienceInternship ExperienceLevel = iota + 1
+	ExperienceEntryLevel
+	ExperienceAssociate
+	ExperienceMidSenior
+	ExperienceDirector
+	ExperienceExecutive
+)
+
+// JobType is a LinkedIn job type filter value (f_JT)
+type JobType string
+
+const (
+	JobTypeFullTime   JobType = "F"
+	JobTypePartTime   JobType = "P"
+	JobTypeContract   JobType = "C"
+	JobTypeTemporary  JobType = "T"
+	JobTypeInternship JobType = "I"
+	JobTypeVolunteer  JobType = "V"
+	JobTypeOther      JobType = "O"
+)
+
+type SearchQuery struct {
+	Keywords string `json:"keywords"`
+	Location string `json:"location"`
+	FWT      string `json:"f_WT"`  // Work type filter (1=onsite, 2=remote, 3=hybrid)
+	GeoId    string `json:"geoId"` // Geographic location ID
+
+	ExperienceLevels []ExperienceLevel `json:"f_E,omitempty"`  // Experience level filter
+	JobTypes         []JobType         `json:"f_JT,omitempty"` // Job type filter
+	CompanyIDs       []string          `json:"f_C,omitempty"`  // Numeric LinkedIn company IDs
+	EasyApply        bool              `json:"f_AL,omitempty"` // Only Easy Apply postings
+	FewApplicants    bool              `json:"f_EA,omitempty"` // Only postings with under 10 applicants
+}
+
+var (
+	workTypeFilterPattern = regexp.MustCompile(`^[123](,[123])*$`)
+	numericIDPattern      = regexp.MustCompile(`^\d+$`)
+)
+
+// Validate checks that every filter holds a value LinkedIn understands
+func (q SearchQuery) Validate() error {
+	if q.FWT != "" && !workTypeFilterPattern.MatchString(q.FWT) {
+		return fmt.Errorf("invalid work type filter %q, expected comma separated values of 1, 2 and 3", q.FWT)
+	}
+	// Each value at most once, which also keeps the filter within the work_type_filter column
+	seen := make(map[string]bool)
+	for _, value := range strings.Split(q.FWT, ",") {
+		if seen[value] {
+			return fmt.Errorf("invalid work type filter %q, each of 1, 2 and 3 can be given once", q.FWT)
+		}
+		seen[value] = true
+	}
+	if q.GeoId != "" && !numericIDPattern.MatchString(q.GeoId) {
+		return fmt.Errorf("invalid geo ID %q, expected a number", q.GeoId)
+	}
+	for _, level := range q.ExperienceLevels {
+		if level < ExperienceInternship || level > ExperienceExecutive {
+			return fmt.Errorf("invalid experience level %d, expected 1 to 6", level)
+		}
+	}
+	for _, jobType := range q.JobTypes {
+		switch jobType {
+		case JobTypeFullTime, JobTypePartTime, JobTypeContract, JobTypeTemporary,
+			JobTypeInternship, JobTypeVolunteer, JobTypeOther:
+		default:
+			return fmt.Errorf("invalid job type %q", jobType)
+		}
+	}
+	for _, id := range q.CompanyIDs {
+		if !numericIDPattern.MatchString(id) {
+			return fmt.Errorf("invalid company ID %q, expected a number", id)
+		}
+	}
+	return nil
+}
+
+// ExperienceLevelFilter formats the experience levels as the f_E parameter value
+func (q SearchQuery) ExperienceLevelFilter() string {
+	levels := make([]string, 0, len(q.ExperienceLevels))
+	for _, level := range q.ExperienceLevels {
+		levels = append(levels, strconv.Itoa(int(level)))
+	}
+	return strings.Join(levels, ",")
+}
+
+// JobTypeFilter formats the job types as the f_JT parameter value
+func (q SearchQuery) JobTypeFilter() string {
+	jobTypes := make([]string, 0, len(q.JobTypes))
+	for _, jobType := range q.JobTypes {
+		jobTypes = append(jobTypes, string(jobType))
+	}
+	return strings.Join(jobTypes, ",")
+}
+
+// Key identifies the query, two queries with the same key run the same search
+func (q SearchQuery) Key() string {
+	parts := []string{
+		strings.ToLower(strings.TrimSpace(q.Keywords)),
+		strings.ToLower(strings.TrimSpace(q.Location)),
+		q.FWT,
+		q.GeoId,
+	}
+
+	// Optional filters only extend the key when set so keys of plain queries stay stable
+	if filters := q.filterKey(); filters != "" {
+		parts = append(parts, filters)
+	}
+
+	return strings.Join(parts, "|")
+}
+
+func (q SearchQuery) filterKey() string {
+	var filters []string
+	if f := q.ExperienceLevelFilter(); f != "" {
+		filters = append(filters, "f_E="+f)
+	}
+	if f := q.JobTypeFilter(); f != "" {
+		filters = append(filters, "f_JT="+f)
+	}
+	if len(q.CompanyIDs) > 0 {
+		filters = append(filters, "f_C="+strings.Join(q.CompanyIDs, ","))
+	}
+	if q.EasyApply {
+		filters = append(filters, "f_AL")
+	}
+	if q.FewApplicants {
+		filters = append(filters, "f_EA")
+	}
+	return strings.Join(filters, "&")
+}
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSearchQueryValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   SearchQuery
+		wantErr string // Part of the error message, empty when the query is valid
+	}{
+		{name: "empty query", query: SearchQuery{}},
+		{
+			name: "every filter set",
+			query: SearchQuery{
+				Keywords:         "Go",
+				Location:         "Japan",
+				FWT:              "1,2,3",
+				GeoId:            "101355337",
+				ExperienceLevels: []ExperienceLevel{ExperienceInternship, ExperienceExecutive},
+				JobTypes:         []JobType{JobTypeFullTime, JobTypePartTime, JobTypeContract, JobTypeTemporary, JobTypeInternship, JobTypeVolunteer, JobTypeOther},
+				CompanyIDs:       []string{"1441"},
+				EasyApply:        true,
+				FewApplicants:    true,
+			},
+		},
+		{name: "work type out of range", query: SearchQuery{FWT: "2,4"}, wantErr: `invalid work type filter "2,4"`},
+		{name: "work type with spaces", query: SearchQuery{FWT: "2, 3"}, wantErr: "invalid work type filter"},
+		{name: "work type trailing comma", query: SearchQuery{FWT: "2,"}, wantErr: "invalid work type filter"},
+		{name: "work type repeated", query: SearchQuery{FWT: "1,2,3,1,2,3"}, wantErr: `invalid work type filter "1,2,3,1,2,3", each of 1, 2 and 3 can be given once`},
+		{name: "geo ID not a number", query: SearchQuery{GeoId: "tokyo"}, wantErr: `invalid geo ID "tokyo"`},
+		{name: "experience level too low", query: SearchQuery{ExperienceLevels: []ExperienceLevel{0}}, wantErr: "invalid experience level 0"},
+		{name: "experience level too high", query: SearchQuery{ExperienceLevels: []ExperienceLevel{7}}, wantErr: "invalid experience level 7"},
+		{name: "unknown job type", query: SearchQuery{JobTypes: []JobType{"X"}}, wantErr: `invalid job type "X"`},
+		{name: "lower case job type", query: SearchQuery{JobTypes: []JobType{"f"}}, wantErr: `invalid job type "f"`},
+		{name: "company ID not a number", query: SearchQuery{CompanyIDs: []string{"1441", "google"}}, wantErr: `invalid company ID "google"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.query.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() = %v, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,200 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/jobs-scraper/internal/models"
+	"github.com/jobs-scraper/internal/utils"
+)
+
+const ashbyBaseURL = "https://api.ashbyhq.com/posting-api/job-board"
+
+type AshbyConfig struct {
+	Organizations []string          // Ashby job board names, e.g. "ramp" for jobs.ashbyhq.com/ramp
+	BaseURL       string            // Posting API base URL, overridable for tests
+	Retry         utils.RetryConfig // Retry settings for API requests
+}
+
+// AshbySource pulls postings from the public Ashby posting API
+type AshbySource struct {
+	*sourceClient
+
+	config       AshbyConfig
+	descriptions *descriptionCache
+}
+
+type ashbyJobBoardResponse struct {
+	Jobs []ashbyPosting `json:"jobs"`
+}
+
+type ashbyPosting struct {
+	ID              string `json:"id"`
+	Title           string `json:"title"`
+	Location        string `json:"location"`
+	Department      string `json:"department"`
+	Team            string `json:"team"`
+	IsListed        bool   `json:"isListed"`
+	IsRemote        bool   `json:"isRemote"`
+	WorkplaceType   string `json:"workplaceType"`  // "OnSite", "Hybrid" or "Remote"
+	EmploymentType  string `json:"employmentType"` // e.g. "FullTime", "Contract"
+	DescriptionHTML string `json:"descriptionHtml"`
+	JobURL          string `json:"jobUrl"`
+	Compensation    *struct {
+		Summary    string `json:"compensationTierSummary"`
+		Components []struct {
+			CompensationType string  `json:"compensationType"` // "Salary", "EquityPercentage", ...
+			Interval         string  `json:"interval"`         // e.g. "1 YEAR"
+			CurrencyCode     string  `json:"currencyCode"`
+			MinValue         float64 `json:"minValue"`
+			MaxValue         float64 `json:"maxValue"`
+		} `json:"summaryComponents"`
+	} `json:"compensation"`
+}
+
+func NewAshbySource(config AshbyConfig) *AshbySource {
+	if config.BaseURL == "" {
+		config.BaseURL = ashbyBaseURL
+	}
+	config.Retry = withRetryDefaults(config.Retry)
+
+	return &AshbySource{
+		sourceClient: newSourceClient(config.Retry, apiSourceTimeout, apiSourceLimits, utils.BreakerConfig{}),
+		config:       config,
+		descriptions: newDescriptionCache(),
+	}
+}
+
+// Name returns the Ashby source name
+func (a *AshbySource) Name() string {
+	return models.SourceAshby
+}
+
+// ListJobs lists the postings of every configured organization whose title matches the search keywords.
+// The job board API is not paginated, so numPages is ignored.
+func (a *AshbySource) ListJobs(ctx context.Context, numPages int, params models.SearchQuery, jobChan chan<- models.Job) error {
+	for _, org := range a.config.Organizations {
+		var res ashbyJobBoardResponse
+		boardURL := fmt.Sprintf("%s/%s?includeCompensation=true", strings.TrimRight(a.config.BaseURL, "/"), url.PathEscape(org))
+		if err := a.getJSON(ctx, boardURL, &res); err != nil {
+			return fmt.Errorf("error fetching ashby job board for %s: %w", org, err)
+		}
+
+		for _, posting := range res.Jobs {
+			if !posting.IsListed || !matchesKeywords(posting.Title, params.Keywords) {
+				continue
+			}
+
+			job := models.Job{
+				ID:            syntheticJobID(models.SourceAshby, posting.ID),
+				Source:        models.SourceAshby,
+				Title:         truncate(strings.TrimSpace(posting.Title), jobTextLength),
+				Company:       truncate(org, jobTextLength),
+				CompanyLink:   "https://jobs.ashbyhq.com/" + org,
+				Location:      truncate(strings.TrimSpace(posting.Location), jobTextLength),
+				JobLink:       posting.JobURL,
+				WorkplaceType: ashbyWorkplaceType(posting.WorkplaceType),
+			}
+			job.Remote = posting.IsRemote || job.WorkplaceType == models.WorkplaceRemote
+
+			if posting.Compensation != nil {
+				for _, component := range posting.Compensation.Components {
+					if component.CompensationType != "Salary" {
+						continue
+					}
+					job.SalaryMin = component.MinValue
+					job.SalaryMax = component.MaxValue
+					job.SalaryCurrency = strings.ToUpper(component.CurrencyCode)
+					job.SalaryPeriod = ashbySalaryPeriod(component.Interval)
+					break
+				}
+			}
+
+			description, err := parseHTMLFragment(posting.DescriptionHTML)
+			if err != nil {
+				return fmt.Errorf("error parsing ashby posting %s: %w", posting.ID, err)
+			}
+
+			a.descriptions.put(models.JobDescription{
+				JobID:               job.ID,
+				Source:              models.SourceAshby,
+				Description:         description.Text,
+				DescriptionMarkdown: description.Markdown,
+				DescriptionHTML:     description.HTML,
+				Criteria:            ashbyCriteria(posting, job),
+			})
+
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case jobChan <- job:
+			}
+		}
+	}
+
+	return nil
+}
+
+// FetchJobDescription returns the description that came with the listing
+func (a *AshbySource) FetchJobDescription(ctx context.Context, job models.Job) (models.JobDescription, error) {
+	return a.descriptions.get(job)
+}
+
+func ashbyCriteria(posting ashbyPosting, job models.Job) map[string]string {
+	criteria := make(map[string]string)
+
+	if posting.EmploymentType != "" {
+		criteria["Employment type"] = posting.EmploymentType
+	}
+	if posting.Department != "" {
+		criteria["Department"] = posting.Department
+	}
+	if posting.Team != "" {
+		criteria["Team"] = posting.Team
+	}
+	if job.Location != "" {
+		criteria["Location"] = job.Location
+	}
+	if job.WorkplaceType != "" {
+		criteria["Workplace type"] = job.WorkplaceType
+	}
+	if compensation := formatSalary(job); compensation != "" {
+		criteria["Compensation"] = compensation
+	} else if posting.Compensation != nil && posting.Compensation.Summary != "" {
+		criteria["Compensation"] = posting.Compensation.Summary
+	}
+
+	return criteria
+}
+
+func ashbyWorkplaceType(workplaceType string) string {
+	switch workplaceType {
+	case "OnSite":
+		return models.WorkplaceOnsite
+	case "Hybrid":
+		return models.WorkplaceHybrid
+	case "Remote":
+		return models.WorkplaceRemote
+	default:
+		return ""
+	}
+}
+
+func ashbySalaryPeriod(interval string) string {
+	switch interval {
+	case "1 HOUR":
+		return models.SalaryPeriodHour
+	case "1 DAY":
+		return models.SalaryPeriodDay
+	case "1 WEEK":
+		return models.SalaryPeriodWeek
+	case "1 MONTH":
+		return models.SalaryPeriodMonth
+	case "1 YEAR":
+		return models.SalaryPeriodYear
+	default:
+		return ""
+	}
+}
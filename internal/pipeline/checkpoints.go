@@ -0,0 +1,67 @@
+package pipeline
+
+import (
+	"sync"
+
+	"github.com/jobs-scraper/internal/models"
+	"github.com/jobs-scraper/internal/repo"
+)
+
+// PageCheckpoints tracks the progress of a run so it can be resumed
+type PageCheckpoints interface {
+	// StartPage returns the first page of query that still has to be fetched from source
+	StartPage(source string, query models.SearchQuery) int
+
+	// PageDone records that page of query was fetched from source and listed jobs
+	PageDone(source string, query models.SearchQuery, page int, jobs []models.Job) error
+
+	// JobsSaved records that the descriptions of jobs were saved
+	JobsSaved(jobs []models.Job) error
+}
+
+// noCheckpoints is used by runs that cannot be resumed
+type noCheckpoints struct{}
+
+func (noCheckpoints) StartPage(string, models.SearchQuery) int                     { return 0 }
+func (noCheckpoints) PageDone(string, models.SearchQuery, int, []models.Job) error { return nil }
+func (noCheckpoints) JobsSaved([]models.Job) error                                 { return nil }
+
+// runCheckpoints stores the progress of a scrape run in the scrape_runs tables
+type runCheckpoints struct {
+	runID   int64
+	runRepo *repo.ScrapeRunRepository
+
+	mu    sync.Mutex
+	pages map[string]map[string]int // Pages done by source and query key
+}
+
+func newRunCheckpoints(runID int64, runRepo *repo.ScrapeRunRepository, pages map[string]map[string]int) *runCheckpoints {
+	if pages == nil {
+		pages = make(map[string]map[string]int)
+	}
+	return &runCheckpoints{runID: runID, runRepo: runRepo, pages: pages}
+}
+
+func (c *runCheckpoints) StartPage(source string, query models.SearchQuery) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.pages[source][query.Key()]
+}
+
+func (c *runCheckpoints) PageDone(source string, query models.SearchQuery, page int, jobs []models.Job) error {
+	if err := c.runRepo.SavePage(c.runID, source, query.Key(), page+1, jobs); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.pages[source] == nil {
+		c.pages[source] = make(map[string]int)
+	}
+	c.pages[source][query.Key()] = page + 1
+	return nil
+}
+
+func (c *runCheckpoints) JobsSaved(jobs []models.Job) error {
+	return c.runRepo.RemovePendingJobs(c.runID, jobs)
+}
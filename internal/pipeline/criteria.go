@@ -0,0 +1,313 @@
+package pipeline
+
+import (
+	"log"
+	"strings"
+
+	"github.com/jobs-scraper/internal/models"
+)
+
+// Criteria fields the localized labels of the sources map to
+const (
+	criterionSeniority      = "seniority"
+	criterionEmploymentType = "employment_type"
+	criterionJobFunctions   = "job_functions"
+	criterionIndustries     = "industries"
+)
+
+// criteriaLabels maps the criteria labels of the sources, in English and Japanese, to fields
+var criteriaLabels = map[string]string{
+	"seniority level": criterionSeniority,
+	"役職レベル":           criterionSeniority,
+	"employment type": criterionEmploymentType,
+	"commitment":      criterionEmploymentType, // Lever
+	"雇用形態":            criterionEmploymentType,
+	"job function":    criterionJobFunctions,
+	"職種":              criterionJobFunctions,
+	"industries":      criterionIndustries,
+	"業種":              criterionIndustries,
+}
+
+// seniorityValues maps localized seniority levels, compacted with criteriaValueKey
+var seniorityValues = map[string]string{
+	"internship":     models.SeniorityInternship,
+	"インターンシップ":       models.SeniorityInternship,
+	"entrylevel":     models.SeniorityEntryLevel,
+	"エントリーレベル":       models.SeniorityEntryLevel,
+	"associate":      models.SeniorityAssociate,
+	"アソシエイト":         models.SeniorityAssociate,
+	"midseniorlevel": models.SeniorityMidSenior,
+	"中間管理職":          models.SeniorityMidSenior,
+	"director":       models.SeniorityDirector,
+	"ディレクター":         models.SeniorityDirector,
+	"executive":      models.SeniorityExecutive,
+	"エグゼクティブ":        models.SeniorityExecutive,
+	"notapplicable":  models.SeniorityNotApplicable,
+	"該当なし":           models.SeniorityNotApplicable,
+}
+
+// employmentTypeValues maps localized employment types, compacted with criteriaValueKey
+var employmentTypeValues = map[string]string{
+	"fulltime":   models.EmploymentFullTime,
+	"正社員":        models.EmploymentFullTime,
+	"フルタイム":      models.EmploymentFullTime,
+	"parttime":   models.EmploymentPartTime,
+	"パートタイム":     models.EmploymentPartTime,
+	"contract":   models.EmploymentContract,
+	"contractor": models.EmploymentContract,
+	"契約":         models.EmploymentContract,
+	"契約社員":       models.EmploymentContract,
+	"temporary":  models.EmploymentTemporary,
+	"臨時":         models.EmploymentTemporary,
+	"派遣社員":       models.EmploymentTemporary,
+	"internship": models.EmploymentInternship,
+	"intern":     models.EmploymentInternship,
+	"インターンシップ":   models.EmploymentInternship,
+	"volunteer":  models.EmploymentVolunteer,
+	"ボランティア":     models.EmploymentVolunteer,
+	"other":      models.EmploymentOther,
+	"その他":        models.EmploymentOther,
+}
+
+// jobFunctionValues maps localized LinkedIn job functions to their English names, compacted with
+// criteriaValueKey
+var jobFunctionValues = map[string]string{
+	"accounting/auditing":   "Accounting/Auditing",
+	"会計/監査":                 "Accounting/Auditing",
+	"administrative":        "Administrative",
+	"管理":                    "Administrative",
+	"advertising":           "Advertising",
+	"広告":                    "Advertising",
+	"analyst":               "Analyst",
+	"アナリスト":                 "Analyst",
+	"art/creative":          "Art/Creative",
+	"アート/クリエイティブ":           "Art/Creative",
+	"businessdevelopment":   "Business Development",
+	"事業開発":                  "Business Development",
+	"consulting":            "Consulting",
+	"コンサルティング":              "Consulting",
+	"customerservice":       "Customer Service",
+	"カスタマーサービス":             "Customer Service",
+	"design":                "Design",
+	"デザイン":                  "Design",
+	"distribution":          "Distribution",
+	"流通":                    "Distribution",
+	"education":             "Education",
+	"教育":                    "Education",
+	"engineering":           "Engineering",
+	"エンジニアリング":              "Engineering",
+	"finance":               "Finance",
+	"財務":                    "Finance",
+	"generalbusiness":       "General Business",
+	"一般業務":                  "General Business",
+	"healthcareprovider":    "Health Care Provider",
+	"医療提供者":                 "Health Care Provider",
+	"humanresources":        "Human Resources",
+	"人事":                    "Human Resources",
+	"informationtechnology": "Information Technology",
+	"情報技術":                  "Information Technology",
+	"legal":                 "Legal",
+	"法務":                    "Legal",
+	"management":            "Management",
+	"マネジメント":                "Management",
+	"manufacturing":         "Manufacturing",
+	"製造":                    "Manufacturing",
+	"marketing":             "Marketing",
+	"マーケティング":               "Marketing",
+	"other":                 "Other",
+	"その他":                   "Other",
+	"productmanagement":     "Product Management",
+	"プロダクトマネジメント":           "Product Management",
+	"production":            "Production",
+	"生産":                    "Production",
+	"projectmanagement":     "Project Management",
+	"プロジェクトマネジメント":          "Project Management",
+	"publicrelations":       "Public Relations",
+	"広報":                    "Public Relations",
+	"purchasing":            "Purchasing",
+	"購買":                    "Purchasing",
+	"qualityassurance":      "Quality Assurance",
+	"品質保証":                  "Quality Assurance",
+	"research":              "Research",
+	"研究":                    "Research",
+	"sales":                 "Sales",
+	"営業":                    "Sales",
+	"science":               "Science",
+	"科学":                    "Science",
+	"strategy/planning":     "Strategy/Planning",
+	"戦略/企画":                 "Strategy/Planning",
+	"supplychain":           "Supply Chain",
+	"サプライチェーン":              "Supply Chain",
+	"training":              "Training",
+	"研修":                    "Training",
+	"writing/editing":       "Writing/Editing",
+	"執筆/編集":                 "Writing/Editing",
+}
+
+// industryValues maps localized LinkedIn industries to their English names, compacted with
+// criteriaValueKey. Names listing several parts are keyed without their separators.
+var industryValues = map[string]string{
+	"softwaredevelopment":              "Software Development",
+	"ソフトウェア開発":                         "Software Development",
+	"itservicesanditconsulting":        "IT Services and IT Consulting",
+	"itサービス・itコンサルティング":                "IT Services and IT Consulting",
+	"technologyinformationandinternet": "Technology, Information and Internet",
+	"テクノロジー情報インターネット":                  "Technology, Information and Internet",
+	"computerandnetworksecurity":       "Computer and Network Security",
+	"コンピュータ・ネットワークセキュリティ":              "Computer and Network Security",
+	"computergames":                    "Computer Games",
+	"コンピュータゲーム":                        "Computer Games",
+	"computerhardwaremanufacturing":    "Computer Hardware Manufacturing",
+	"コンピュータハードウェア製造":                   "Computer Hardware Manufacturing",
+	"semiconductormanufacturing":       "Semiconductor Manufacturing",
+	"半導体製造":                            "Semiconductor Manufacturing",
+	"telecommunications":               "Telecommunications",
+	"電気通信":                             "Telecommunications",
+	"financialservices":                "Financial Services",
+	"金融サービス":                           "Financial Services",
+	"banking":                          "Banking",
+	"銀行":                               "Banking",
+	"insurance":                        "Insurance",
+	"保険":                               "Insurance",
+	"staffingandrecruiting":            "Staffing and Recruiting",
+	"人材派遣・採用":                          "Staffing and Recruiting",
+	"humanresourcesservices":           "Human Resources Services",
+	"人事サービス":                           "Human Resources Services",
+	"businessconsultingandservices":    "Business Consulting and Services",
+	"ビジネスコンサルティング・サービス":                "Business Consulting and Services",
+	"advertisingservices":              "Advertising Services",
+	"広告サービス":                           "Advertising Services",
+	"retail":                           "Retail",
+	"小売":                               "Retail",
+	"manufacturing":                    "Manufacturing",
+	"製造":                               "Manufacturing",
+	"motorvehiclemanufacturing":        "Motor Vehicle Manufacturing",
+	"自動車製造":                            "Motor Vehicle Manufacturing",
+	"pharmaceuticalmanufacturing":      "Pharmaceutical Manufacturing",
+	"医薬品製造":                            "Pharmaceutical Manufacturing",
+	"hospitalsandhealthcare":           "Hospitals and Health Care",
+	"病院・ヘルスケア":                         "Hospitals and Health Care",
+	"education":                        "Education",
+	"教育":                               "Education",
+	"highereducation":                  "Higher Education",
+	"高等教育":                             "Higher Education",
+	"elearningproviders":               "E-Learning Providers",
+	"eラーニングプロバイダー":                     "E-Learning Providers",
+	"realestate":                       "Real Estate",
+	"不動産":                              "Real Estate",
+	"construction":                     "Construction",
+	"建設":                               "Construction",
+	"entertainmentproviders":           "Entertainment Providers",
+	"エンターテインメントプロバイダー": "Entertainment Providers",
+	"travelarrangements":      "Travel Arrangements",
+	"旅行手配":                    "Travel Arrangements",
+	"hospitality":             "Hospitality",
+	"ホスピタリティ":                 "Hospitality",
+	"foodandbeverageservices": "Food and Beverage Services",
+	"飲食サービス":                  "Food and Beverage Services",
+	"transportationlogisticssupplychainandstorage": "Transportation, Logistics, Supply Chain and Storage",
+	"運輸・物流・サプライチェーン・倉庫":                            "Transportation, Logistics, Supply Chain and Storage",
+	"researchservices":         "Research Services",
+	"調査・研究サービス":                "Research Services",
+	"nonprofitorganizations":   "Non-profit Organizations",
+	"非営利団体":                    "Non-profit Organizations",
+	"governmentadministration": "Government Administration",
+	"行政":                       "Government Administration",
+}
+
+// structureCriteria fills the typed criteria fields of jd from its localized criteria map.
+// Values that cannot be normalized stay only in the map.
+func structureCriteria(jd *models.JobDescription) {
+	for label, value := range jd.Criteria {
+		switch criteriaLabels[strings.ToLower(strings.TrimSpace(label))] {
+		case criterionSeniority:
+			jd.Seniority = normalizeCriterion(seniorityValues, label, value)
+		case criterionEmploymentType:
+			jd.EmploymentType = normalizeCriterion(employmentTypeValues, label, value)
+		case criterionJobFunctions:
+			jd.JobFunctions = normalizeListCriterion(jobFunctionValues, label, value)
+		case criterionIndustries:
+			jd.Industries = normalizeListCriterion(industryValues, label, value)
+		}
+	}
+}
+
+func normalizeCriterion(values map[string]string, label, value string) string {
+	normalized, ok := values[criteriaValueKey(value)]
+	if !ok {
+		log.Printf("Unknown %q criterion %q", label, value)
+	}
+	return normalized
+}
+
+// normalizeListCriterion splits a list criterion and maps its items to their English names.
+// Unknown items are kept as they are.
+func normalizeListCriterion(values map[string]string, label, value string) []string {
+	parts := splitCriterion(value)
+
+	items := make([]string, 0, len(parts))
+	for len(parts) > 0 {
+		n, names := matchListItem(values, parts)
+		if n == 0 {
+			log.Printf("Unknown %q criterion %q", label, parts[0])
+			n, names = 1, parts[:1]
+		}
+		items = append(items, names...)
+		parts = parts[n:]
+	}
+	return items
+}
+
+// matchListItem returns how many of the leading parts form a known item and its names. Longer
+// runs are tried first, since some names contain the separators, e.g. "Technology, Information
+// and Internet". A part joining known items with "and", as in "Engineering and Information
+// Technology", yields each of them.
+func matchListItem(values map[string]string, parts []string) (int, []string) {
+	for n := len(parts); n > 0; n-- {
+		if name, ok := values[criteriaValueKey(strings.Join(parts[:n], ""))]; ok {
+			return n, []string{name}
+		}
+	}
+
+	pieces := strings.Split(parts[0], " and ")
+	if len(pieces) < 2 {
+		return 0, nil
+	}
+	names := make([]string, 0, len(pieces))
+	for _, piece := range pieces {
+		name, ok := values[criteriaValueKey(piece)]
+		if !ok {
+			return 0, nil
+		}
+		names = append(names, name)
+	}
+	return 1, names
+}
+
+// criteriaValueKey lowercases value and drops spaces, hyphens and underscores, so "Full-time",
+// "FullTime" and "full_time" are the same key
+func criteriaValueKey(value string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '_', '　':
+			return -1
+		}
+		return r
+	}, strings.ToLower(strings.TrimSpace(value)))
+}
+
+// splitCriterion splits list criteria such as "Engineering, Information Technology, and Sales"
+// or "エンジニアリング、情報技術"
+func splitCriterion(value string) []string {
+	parts := strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == '、' })
+
+	items := make([]string, 0, len(parts))
+	for _, part := range parts {
+		part = strings.TrimSpace(part)
+		part = strings.TrimSpace(strings.TrimPrefix(part, "and "))
+		if part != "" {
+			items = append(items, part)
+		}
+	}
+	return items
+}
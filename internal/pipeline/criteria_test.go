@@ -0,0 +1,75 @@
+package pipeline
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jobs-scraper/internal/models"
+)
+
+func TestStructureCriteria(t *testing.T) {
+	tests := []struct {
+		name          string
+		criteria      map[string]string
+		wantFunctions []string
+		wantIndustry  []string
+	}{
+		{
+			name: "english",
+			criteria: map[string]string{
+				"Job function": "Engineering and Information Technology",
+				"Industries":   "Software Development",
+			},
+			wantFunctions: []string{"Engineering", "Information Technology"},
+			wantIndustry:  []string{"Software Development"},
+		},
+		{
+			name: "japanese",
+			criteria: map[string]string{
+				"職種": "エンジニアリング、情報技術",
+				"業種": "ソフトウェア開発",
+			},
+			wantFunctions: []string{"Engineering", "Information Technology"},
+			wantIndustry:  []string{"Software Development"},
+		},
+		{
+			name: "names containing separators",
+			criteria: map[string]string{
+				"Job function": "Research, Analyst, and Information Technology",
+				"Industries":   "Technology, Information and Internet, IT Services and IT Consulting",
+			},
+			wantFunctions: []string{"Research", "Analyst", "Information Technology"},
+			wantIndustry:  []string{"Technology, Information and Internet", "IT Services and IT Consulting"},
+		},
+		{
+			name: "japanese names containing separators",
+			criteria: map[string]string{
+				"業種": "テクノロジー、情報、インターネット、ITサービス・ITコンサルティング",
+			},
+			wantIndustry: []string{"Technology, Information and Internet", "IT Services and IT Consulting"},
+		},
+		{
+			name: "unknown items are kept",
+			criteria: map[string]string{
+				"Job function": "Sales and Alchemy",
+				"Industries":   "Space Research, Banking",
+			},
+			wantFunctions: []string{"Sales and Alchemy"},
+			wantIndustry:  []string{"Space Research", "Banking"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jd := models.JobDescription{Criteria: tt.criteria}
+			structureCriteria(&jd)
+
+			if !reflect.DeepEqual(jd.JobFunctions, tt.wantFunctions) {
+				t.Errorf("job functions %q, want %q", jd.JobFunctions, tt.wantFunctions)
+			}
+			if !reflect.DeepEqual(jd.Industries, tt.wantIndustry) {
+				t.Errorf("industries %q, want %q", jd.Industries, tt.wantIndustry)
+			}
+		})
+	}
+}
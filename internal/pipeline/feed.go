@@ -0,0 +1,314 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/jobs-scraper/internal/models"
+	"github.com/jobs-scraper/internal/utils"
+)
+
+const hackerNewsItemURL = "https://news.ycombinator.com/item?id="
+
+type FeedConfig struct {
+	FeedURLs        []string          // RSS 2.0 or Atom feeds listing job postings
+	HackerNewsFiles []string          // Local JSON dumps of "Who is hiring" threads from the HN Algolia items API
+	Retry           utils.RetryConfig // Retry settings for feed requests
+}
+
+// FeedSource turns entries of job feeds and "Who is hiring" comments into jobs
+type FeedSource struct {
+	*sourceClient
+
+	config       FeedConfig
+	descriptions *descriptionCache
+}
+
+// feedDocument decodes both RSS (<rss><channel><item>) and Atom (<feed><entry>) documents
+type feedDocument struct {
+	Channel struct {
+		Title string    `xml:"title"`
+		Items []rssItem `xml:"item"`
+	} `xml:"channel"`
+	Title   string      `xml:"title"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type rssItem struct {
+	Title       string `xml:"title"`
+	Link        string `xml:"link"`
+	GUID        string `xml:"guid"`
+	Description string `xml:"description"`
+	Content     string `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+}
+
+type atomEntry struct {
+	ID    string `xml:"id"`
+	Title string `xml:"title"`
+	Links []struct {
+		Href string `xml:"href,attr"`
+		Rel  string `xml:"rel,attr"`
+	} `xml:"link"`
+	Content string `xml:"content"`
+	Summary string `xml:"summary"`
+	Author  struct {
+		Name string `xml:"name"`
+	} `xml:"author"`
+}
+
+// hackerNewsItem is an item of the HN Algolia API, the thread itself or one of its comments
+type hackerNewsItem struct {
+	ID       int64            `json:"id"`
+	Author   string           `json:"author"`
+	Text     string           `json:"text"`
+	Children []hackerNewsItem `json:"children"`
+}
+
+// feedEntry is a feed item, Atom entry or HN comment reduced to what a job needs
+type feedEntry struct {
+	key      string // Stable identifier of the entry used to derive the job ID
+	title    string
+	company  string
+	location string
+	link     string
+	body     string // HTML body of the entry
+}
+
+func NewFeedSource(config FeedConfig) *FeedSource {
+	config.Retry = withRetryDefaults(config.Retry)
+
+	return &FeedSource{
+		sourceClient: newSourceClient(config.Retry, apiSourceTimeout, apiSourceLimits, utils.BreakerConfig{}),
+		config:       config,
+		descriptions: newDescriptionCache(),
+	}
+}
+
+// Name returns the feed source name
+func (f *FeedSource) Name() string {
+	return models.SourceFeed
+}
+
+// ListJobs lists the feed entries and HN comments matching the search keywords.
+// Feeds are not paginated, so numPages is ignored.
+func (f *FeedSource) ListJobs(ctx context.Context, numPages int, params models.SearchQuery, jobChan chan<- models.Job) error {
+	for _, feedURL := range f.config.FeedURLs {
+		var doc feedDocument
+		if err := f.getXML(ctx, feedURL, &doc); err != nil {
+			return fmt.Errorf("error fetching feed %s: %w", feedURL, err)
+		}
+
+		for _, entry := range feedEntries(doc) {
+			if !matchesKeywords(entry.title, params.Keywords) {
+				continue
+			}
+			if err := f.sendEntry(ctx, entry, jobChan); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, path := range f.config.HackerNewsFiles {
+		entries, err := readHackerNewsFile(path)
+		if err != nil {
+			return err
+		}
+
+		for _, entry := range entries {
+			// Comments rarely have a clean title, so match against the whole posting
+			if !matchesKeywords(entry.title+" "+entry.body, params.Keywords) {
+				continue
+			}
+			if err := f.sendEntry(ctx, entry, jobChan); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// FetchJobDescription returns the description built from the entry body while listing
+func (f *FeedSource) FetchJobDescription(ctx context.Context, job models.Job) (models.JobDescription, error) {
+	return f.descriptions.get(job)
+}
+
+func (f *FeedSource) sendEntry(ctx context.Context, entry feedEntry, jobChan chan<- models.Job) error {
+	job := models.Job{
+		ID:       syntheticJobID(models.SourceFeed, entry.key),
+		Source:   models.SourceFeed,
+		Title:    truncate(entry.title, jobTextLength),
+		Company:  truncate(entry.company, jobTextLength),
+		Location: truncate(entry.location, jobTextLength),
+		JobLink:  entry.link,
+	}
+
+	description, err := parseHTMLFragment(entry.body)
+	if err != nil {
+		return fmt.Errorf("error parsing feed entry %s: %w", entry.key, err)
+	}
+
+	f.descriptions.put(models.JobDescription{
+		JobID:               job.ID,
+		Source:              models.SourceFeed,
+		Description:         description.Text,
+		DescriptionMarkdown: description.Markdown,
+		DescriptionHTML:     description.HTML,
+		Criteria:            map[string]string{},
+	})
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case jobChan <- job:
+	}
+
+	return nil
+}
+
+// feedEntries flattens the items of an RSS channel or the entries of an Atom feed
+func feedEntries(doc feedDocument) []feedEntry {
+	entries := make([]feedEntry, 0, len(doc.Channel.Items)+len(doc.Entries))
+
+	for _, item := range doc.Channel.Items {
+		entry := feedEntry{
+			key:  firstNonEmpty(item.GUID, item.Link, item.Title),
+			link: firstNonEmpty(item.Link, permalink(item.GUID)),
+			body: firstNonEmpty(item.Content, item.Description),
+		}
+		entry.company, entry.title = splitFeedTitle(item.Title, doc.Channel.Title)
+		entries = appendLinkedEntry(entries, entry)
+	}
+
+	for _, atom := range doc.Entries {
+		entry := feedEntry{
+			key:  firstNonEmpty(atom.ID, atom.Title),
+			body: firstNonEmpty(atom.Content, atom.Summary),
+		}
+		for _, link := range atom.Links {
+			if link.Rel == "" || link.Rel == "alternate" {
+				entry.link = strings.TrimSpace(link.Href)
+				break
+			}
+		}
+		if entry.link == "" {
+			entry.link = permalink(atom.ID)
+		}
+		entry.company, entry.title = splitFeedTitle(atom.Title, firstNonEmpty(atom.Author.Name, doc.Title))
+		entries = appendLinkedEntry(entries, entry)
+	}
+
+	return entries
+}
+
+// appendLinkedEntry appends entry unless it has no link. Jobs are unique by source and link, so
+// a second entry without one would fail the whole batch it is saved in.
+func appendLinkedEntry(entries []feedEntry, entry feedEntry) []feedEntry {
+	if entry.link == "" {
+		fmt.Printf("Skipping feed entry %q without a link\n", firstNonEmpty(entry.key, entry.title))
+		return entries
+	}
+	return append(entries, entry)
+}
+
+// permalink returns an RSS guid or Atom id that is a web address, which most feeds use, and ""
+// for other identifiers such as tag: URIs
+func permalink(id string) string {
+	id = strings.TrimSpace(id)
+	if strings.HasPrefix(id, "https://") || strings.HasPrefix(id, "http://") {
+		return id
+	}
+	return ""
+}
+
+// splitFeedTitle splits "Company: Title" entry titles used by most remote job boards,
+// falling back to the feed or author name as the company
+func splitFeedTitle(title, fallbackCompany string) (company string, jobTitle string) {
+	title = strings.TrimSpace(title)
+	if company, jobTitle, ok := strings.Cut(title, ": "); ok {
+		return strings.TrimSpace(company), strings.TrimSpace(jobTitle)
+	}
+	return strings.TrimSpace(fallbackCompany), title
+}
+
+// readHackerNewsFile reads a thread dump, either the thread item with its children or a plain array of comments
+func readHackerNewsFile(path string) ([]feedEntry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading hacker news dump %s: %w", path, err)
+	}
+
+	var comments []hackerNewsItem
+	if trimmed := strings.TrimSpace(string(data)); strings.HasPrefix(trimmed, "[") {
+		err = json.Unmarshal(data, &comments)
+	} else {
+		var thread hackerNewsItem
+		err = json.Unmarshal(data, &thread)
+		comments = thread.Children
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error decoding hacker news dump %s: %w", path, err)
+	}
+
+	entries := make([]feedEntry, 0, len(comments))
+	for _, comment := range comments {
+		if strings.TrimSpace(comment.Text) == "" {
+			continue
+		}
+		entries = append(entries, hackerNewsEntry(comment))
+	}
+
+	return entries, nil
+}
+
+// hackerNewsEntry reads the conventional "Company | Title | Location | ..." first line of a comment
+func hackerNewsEntry(comment hackerNewsItem) feedEntry {
+	entry := feedEntry{
+		key:     "hn:" + strconv.FormatInt(comment.ID, 10),
+		company: comment.Author,
+		title:   "Who is hiring posting",
+		link:    hackerNewsItemURL + strconv.FormatInt(comment.ID, 10),
+		body:    comment.Text,
+	}
+
+	// HN leaves the first paragraph unwrapped, wrap it so it keeps its own line in the description
+	if !strings.HasPrefix(comment.Text, "<p>") {
+		entry.body = "<p>" + comment.Text
+	}
+
+	firstLine, _, _ := strings.Cut(comment.Text, "<p>")
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(firstLine))
+	if err != nil {
+		return entry
+	}
+
+	parts := strings.Split(doc.Text(), "|")
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+	if len(parts) > 0 && parts[0] != "" {
+		entry.company = parts[0]
+	}
+	if len(parts) > 1 && parts[1] != "" {
+		entry.title = parts[1]
+	}
+	if len(parts) > 2 {
+		entry.location = parts[2]
+	}
+
+	return entry
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,41 @@
+package pipeline
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/jobs-scraper/internal/models"
+)
+
+func TestFeedListJobsSkipsEntriesWithoutLink(t *testing.T) {
+	server := httptest.NewServer(http.FileServer(http.Dir(filepath.Join("testdata", "feeds"))))
+	defer server.Close()
+
+	source := NewFeedSource(FeedConfig{FeedURLs: []string{server.URL + "/jobs.rss", server.URL + "/jobs.atom"}})
+
+	jobChan := make(chan models.Job, 10)
+	if err := source.ListJobs(context.Background(), 1, models.SearchQuery{}, jobChan); err != nil {
+		t.Fatalf("ListJobs: %v", err)
+	}
+	close(jobChan)
+
+	var links []string
+	for job := range jobChan {
+		links = append(links, job.JobLink)
+	}
+
+	// Entries without a link use their guid or id when it is a web address, the others are skipped
+	want := []string{
+		"https://jobs.example.com/acme/senior-go-engineer",
+		"https://jobs.example.com/globex/go-developer",
+		"https://jobs.example.org/umbrella/backend-engineer",
+		"https://jobs.example.org/stark/go-engineer",
+	}
+	if !reflect.DeepEqual(links, want) {
+		t.Errorf("listed jobs linking to\n%v\nwant\n%v", links, want)
+	}
+}
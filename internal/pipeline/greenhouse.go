@@ -0,0 +1,230 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/jobs-scraper/internal/models"
+	"github.com/jobs-scraper/internal/utils"
+)
+
+const (
+	greenhouseBaseURL   = "https://boards-api.greenhouse.io/v1/boards"
+	greenhouseBoardLink = "https://boards.greenhouse.io/" // Public board page, followed by the board token
+)
+
+type GreenhouseConfig struct {
+	BoardTokens []string          // Board tokens of the companies to pull, e.g. "gitlab" for boards.greenhouse.io/gitlab
+	BaseURL     string            // Board API base URL, overridable for tests
+	Retry       utils.RetryConfig // Retry settings for API requests
+}
+
+// GreenhouseSource pulls postings from the public Greenhouse job board API
+type GreenhouseSource struct {
+	*sourceClient
+
+	config GreenhouseConfig
+
+	mu       sync.Mutex
+	postings map[int64]greenhouseJob // Postings seen by ListJobs, keyed by job ID
+	boards   map[int64]string        // Board token each posting was listed on
+}
+
+type greenhouseBoard struct {
+	Name string `json:"name"`
+}
+
+type greenhouseJobsResponse struct {
+	Jobs []greenhouseJob `json:"jobs"`
+}
+
+type greenhouseJob struct {
+	ID          int64  `json:"id"`
+	Title       string `json:"title"`
+	AbsoluteURL string `json:"absolute_url"`
+	Content     string `json:"content"` // HTML-escaped description
+	Location    struct {
+		Name string `json:"name"`
+	} `json:"location"`
+	Departments []struct {
+		Name string `json:"name"`
+	} `json:"departments"`
+	Offices []struct {
+		Name     string `json:"name"`
+		Location string `json:"location"`
+	} `json:"offices"`
+}
+
+func NewGreenhouseSource(config GreenhouseConfig) *GreenhouseSource {
+	if config.BaseURL == "" {
+		config.BaseURL = greenhouseBaseURL
+	}
+	config.Retry = withRetryDefaults(config.Retry)
+
+	return &GreenhouseSource{
+		sourceClient: newSourceClient(config.Retry, apiSourceTimeout, apiSourceLimits, utils.BreakerConfig{}),
+		config:       config,
+		postings:     make(map[int64]greenhouseJob),
+		boards:       make(map[int64]string),
+	}
+}
+
+// Name returns the Greenhouse source name
+func (g *GreenhouseSource) Name() string {
+	return models.SourceGreenhouse
+}
+
+// ListJobs lists the postings of every configured board whose title matches the search keywords.
+// Boards are not paginated, so numPages is ignored.
+func (g *GreenhouseSource) ListJobs(ctx context.Context, numPages int, params models.SearchQuery, jobChan chan<- models.Job) error {
+	return g.ListJobsFrom(ctx, 0, numPages, params, jobChan, nil)
+}
+
+// ListJobsFrom implements ResumableJobSource, each configured board is one page
+func (g *GreenhouseSource) ListJobsFrom(ctx context.Context, startPage, numPages int, params models.SearchQuery, jobChan chan<- models.Job, pageDone func(page int, jobs []models.Job) error) error {
+	for page := startPage; page < len(g.config.BoardTokens); page++ {
+		token := g.config.BoardTokens[page]
+		jobs, err := g.listBoard(ctx, token, params)
+		if err != nil {
+			return err
+		}
+
+		if pageDone != nil {
+			if err := pageDone(page, jobs); err != nil {
+				return fmt.Errorf("error checkpointing greenhouse board %s: %w", token, err)
+			}
+		}
+
+		for _, job := range jobs {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case jobChan <- job:
+			}
+		}
+	}
+
+	return nil
+}
+
+// listBoard returns the postings of the board whose title matches the search keywords
+func (g *GreenhouseSource) listBoard(ctx context.Context, token string, params models.SearchQuery) ([]models.Job, error) {
+	var board greenhouseBoard
+	if err := g.getJSON(ctx, g.boardURL(token), &board); err != nil {
+		return nil, fmt.Errorf("error fetching greenhouse board %s: %w", token, err)
+	}
+	if board.Name == "" {
+		board.Name = token
+	}
+
+	var res greenhouseJobsResponse
+	if err := g.getJSON(ctx, g.boardURL(token)+"/jobs?content=true", &res); err != nil {
+		return nil, fmt.Errorf("error fetching greenhouse jobs for board %s: %w", token, err)
+	}
+
+	var jobs []models.Job
+	for _, posting := range res.Jobs {
+		if !matchesKeywords(posting.Title, params.Keywords) {
+			continue
+		}
+
+		g.mu.Lock()
+		g.postings[posting.ID] = posting
+		g.boards[posting.ID] = token
+		g.mu.Unlock()
+
+		jobs = append(jobs, models.Job{
+			ID:          posting.ID,
+			Source:      models.SourceGreenhouse,
+			Title:       truncate(strings.TrimSpace(posting.Title), jobTextLength),
+			Company:     truncate(board.Name, jobTextLength),
+			CompanyLink: greenhouseBoardLink + token,
+			Location:    truncate(strings.TrimSpace(posting.Location.Name), jobTextLength),
+			JobLink:     posting.AbsoluteURL,
+		})
+	}
+
+	return jobs, nil
+}
+
+// FetchJobDescription builds the description from the content returned while listing
+func (g *GreenhouseSource) FetchJobDescription(ctx context.Context, job models.Job) (models.JobDescription, error) {
+	g.mu.Lock()
+	posting, ok := g.postings[job.ID]
+	token := g.boards[job.ID]
+	g.mu.Unlock()
+
+	if !ok {
+		// Listed by an earlier process of a resumed run, the board token is in the company link
+		if token, ok = strings.CutPrefix(job.CompanyLink, greenhouseBoardLink); !ok || token == "" {
+			return models.JobDescription{}, fmt.Errorf("greenhouse job %d was not listed by this source", job.ID)
+		}
+	}
+
+	if posting.Content == "" {
+		// The listing had no content, fetch the single posting instead
+		if err := g.getJSON(ctx, fmt.Sprintf("%s/jobs/%d", g.boardURL(token), job.ID), &posting); err != nil {
+			return models.JobDescription{}, err
+		}
+	}
+
+	description, err := parseHTMLFragment(html.UnescapeString(posting.Content))
+	if err != nil {
+		return models.JobDescription{}, err
+	}
+	if description.Text == "" {
+		return models.JobDescription{}, fmt.Errorf("job description not found")
+	}
+
+	return models.JobDescription{
+		JobID:               job.ID,
+		Source:              models.SourceGreenhouse,
+		Description:         description.Text,
+		DescriptionMarkdown: description.Markdown,
+		DescriptionHTML:     description.HTML,
+		Criteria:            greenhouseCriteria(posting),
+	}, nil
+}
+
+func (g *GreenhouseSource) boardURL(token string) string {
+	return fmt.Sprintf("%s/%s", strings.TrimRight(g.config.BaseURL, "/"), url.PathEscape(token))
+}
+
+// greenhouseCriteria maps departments and offices into the criteria map
+func greenhouseCriteria(posting greenhouseJob) map[string]string {
+	criteria := make(map[string]string)
+
+	departments := make([]string, 0, len(posting.Departments))
+	for _, d := range posting.Departments {
+		if name := strings.TrimSpace(d.Name); name != "" {
+			departments = append(departments, name)
+		}
+	}
+	if len(departments) > 0 {
+		criteria["Departments"] = strings.Join(departments, ", ")
+	}
+
+	offices := make([]string, 0, len(posting.Offices))
+	for _, o := range posting.Offices {
+		name := strings.TrimSpace(o.Name)
+		if o.Location != "" && o.Location != name {
+			name = fmt.Sprintf("%s (%s)", name, strings.TrimSpace(o.Location))
+		}
+		if name != "" {
+			offices = append(offices, name)
+		}
+	}
+	if len(offices) > 0 {
+		criteria["Offices"] = strings.Join(offices, ", ")
+	}
+
+	if location := strings.TrimSpace(posting.Location.Name); location != "" {
+		criteria["Location"] = location
+	}
+
+	return criteria
+}
@@ -0,0 +1,214 @@
+package pipeline
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/jobs-scraper/internal/models"
+)
+
+// newGreenhouseServer serves the board "acme" from the fixtures in testdata/greenhouse and
+// records the paths that were requested
+func newGreenhouseServer(t *testing.T) (*httptest.Server, func() []string) {
+	t.Helper()
+
+	fixtures := map[string]string{
+		"/acme":           "board.json",
+		"/acme/jobs":      "jobs.json",
+		"/acme/jobs/4003": "job-4003.json",
+	}
+
+	var (
+		mu        sync.Mutex
+		requested []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requested = append(requested, r.URL.RequestURI())
+		mu.Unlock()
+
+		fixture, ok := fixtures[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		data, err := os.ReadFile(filepath.Join("testdata", "greenhouse", fixture))
+		if err != nil {
+			t.Errorf("reading fixture %s: %v", fixture, err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+	}))
+	t.Cleanup(server.Close)
+
+	return server, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), requested...)
+	}
+}
+
+func listGreenhouseJobs(t *testing.T, source *GreenhouseSource, keywords string) []models.Job {
+	t.Helper()
+
+	jobChan := make(chan models.Job, 10)
+	if err := source.ListJobs(context.Background(), 1, models.SearchQuery{Keywords: keywords}, jobChan); err != nil {
+		t.Fatalf("ListJobs: %v", err)
+	}
+	close(jobChan)
+
+	var jobs []models.Job
+	for job := range jobChan {
+		jobs = append(jobs, job)
+	}
+	return jobs
+}
+
+func TestGreenhouseListJobs(t *testing.T) {
+	server, requested := newGreenhouseServer(t)
+	source := NewGreenhouseSource(GreenhouseConfig{BoardTokens: []string{"acme"}, BaseURL: server.URL})
+
+	jobs := listGreenhouseJobs(t, source, "go")
+
+	want := []models.Job{
+		{
+			ID:          4001,
+			Source:      models.SourceGreenhouse,
+			Title:       "Senior Go Engineer",
+			Company:     "Acme Inc",
+			CompanyLink: "https://boards.greenhouse.io/acme",
+			Location:    "Tokyo, Japan",
+			JobLink:     "https://boards.greenhouse.io/acme/jobs/4001",
+		},
+		{
+			ID:          4003,
+			Source:      models.SourceGreenhouse,
+			Title:       "Go Developer (Contract)",
+			Company:     "Acme Inc",
+			CompanyLink: "https://boards.greenhouse.io/acme",
+			Location:    "Remote",
+			JobLink:     "https://boards.greenhouse.io/acme/jobs/4003",
+		},
+	}
+	if !reflect.DeepEqual(jobs, want) {
+		t.Errorf("ListJobs listed\n%+v\nwant\n%+v", jobs, want)
+	}
+
+	wantRequests := []string{"/acme", "/acme/jobs?content=true"}
+	if got := requested(); !reflect.DeepEqual(got, wantRequests) {
+		t.Errorf("requested %v, want %v", got, wantRequests)
+	}
+}
+
+func TestGreenhouseListJobsFromCheckpointsEachBoard(t *testing.T) {
+	server, _ := newGreenhouseServer(t)
+	source := NewGreenhouseSource(GreenhouseConfig{BoardTokens: []string{"done", "acme"}, BaseURL: server.URL})
+
+	jobChan := make(chan models.Job, 10)
+	pages := make(map[int]int)
+	err := source.ListJobsFrom(context.Background(), 1, 1, models.SearchQuery{Keywords: "engineer"}, jobChan, func(page int, jobs []models.Job) error {
+		pages[page] = len(jobs)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ListJobsFrom: %v", err)
+	}
+
+	// The first board was done before the resume and is not requested, which would fail with a 404
+	if want := map[int]int{1: 1}; !reflect.DeepEqual(pages, want) {
+		t.Errorf("checkpointed pages %v, want %v", pages, want)
+	}
+	if len(jobChan) != 1 {
+		t.Errorf("listed %d jobs, want 1", len(jobChan))
+	}
+}
+
+func TestGreenhouseFetchJobDescription(t *testing.T) {
+	server, requested := newGreenhouseServer(t)
+	source := NewGreenhouseSource(GreenhouseConfig{BoardTokens: []string{"acme"}, BaseURL: server.URL})
+	jobs := listGreenhouseJobs(t, source, "go")
+
+	tests := []struct {
+		name         string
+		job          models.Job
+		description  string
+		criteria     map[string]string
+		fetchedAgain bool
+	}{
+		{
+			name:        "content from the listing",
+			job:         jobs[0],
+			description: "Build **backend** services.\n\n• Go\n• PostgreSQL",
+			criteria: map[string]string{
+				"Departments": "Engineering",
+				"Offices":     "Tokyo (Tokyo, Japan)",
+				"Location":    "Tokyo, Japan",
+			},
+		},
+		{
+			name:        "single posting when the listing had no content",
+			job:         jobs[1],
+			description: "Six month contract.",
+			criteria: map[string]string{
+				"Departments": "Platform",
+				"Location":    "Remote",
+			},
+			fetchedAgain: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := len(requested())
+
+			jd, err := source.FetchJobDescription(context.Background(), tt.job)
+			if err != nil {
+				t.Fatalf("FetchJobDescription: %v", err)
+			}
+			if jd.JobID != tt.job.ID || jd.Source != models.SourceGreenhouse {
+				t.Errorf("description of %s job %d, want greenhouse job %d", jd.Source, jd.JobID, tt.job.ID)
+			}
+			if jd.Description != tt.description {
+				t.Errorf("description %q, want %q", jd.Description, tt.description)
+			}
+			if !reflect.DeepEqual(jd.Criteria, tt.criteria) {
+				t.Errorf("criteria %v, want %v", jd.Criteria, tt.criteria)
+			}
+
+			if fetched := len(requested()) > before; fetched != tt.fetchedAgain {
+				t.Errorf("fetched the posting again: %v, want %v", fetched, tt.fetchedAgain)
+			}
+		})
+	}
+}
+
+func TestGreenhouseFetchJobDescriptionOfResumedRun(t *testing.T) {
+	server, requested := newGreenhouseServer(t)
+	// A fresh source that never listed the job, as after a restart
+	source := NewGreenhouseSource(GreenhouseConfig{BoardTokens: []string{"acme"}, BaseURL: server.URL})
+
+	job := models.Job{ID: 4003, Source: models.SourceGreenhouse, CompanyLink: "https://boards.greenhouse.io/acme"}
+	jd, err := source.FetchJobDescription(context.Background(), job)
+	if err != nil {
+		t.Fatalf("FetchJobDescription: %v", err)
+	}
+	if jd.Description != "Six month contract." {
+		t.Errorf("description %q, want %q", jd.Description, "Six month contract.")
+	}
+	if want := []string{"/acme/jobs/4003"}; !reflect.DeepEqual(requested(), want) {
+		t.Errorf("requested %v, want %v", requested(), want)
+	}
+
+	job.CompanyLink = "https://example.com/acme"
+	if _, err := source.FetchJobDescription(context.Background(), job); err == nil {
+		t.Error("FetchJobDescription of a job from an unknown board succeeded")
+	}
+}
@@ -2,32 +2,113 @@ package pipeline
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"sync"
 
 	"github.com/jobs-scraper/internal/models"
 )
 
-func GetJobs(context context.Context, scraperService *Scraper) <-chan models.Job {
-	jobChan := make(chan models.Job, 100)
+// GetJobs runs every query against every source and streams the listed jobs tagged with their query.
+// Resumable sources continue from the pages recorded in checkpoints. The error of every listing
+// that stopped early is sent to the error channel, which is closed together with the job channel.
+func GetJobs(context context.Context, sources []JobSource, numPages int, queries []models.SearchQuery, checkpoints PageCheckpoints) (<-chan MatchedJob, <-chan error) {
+	matchChan := make(chan MatchedJob, 100)
+	// Every listing sends at most one error, so sending never blocks
+	errChan := make(chan error, len(queries)*len(sources))
 
+	var wg sync.WaitGroup
+
+	// Each source lists each query in its own goroutine
+	for _, query := range queries {
+		for _, source := range sources {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				jobChan := make(chan models.Job)
+				go func() {
+					defer close(jobChan)
+					if err := listJobs(context, source, numPages, query, jobChan, checkpoints); err != nil {
+						log.Printf("Error scraping %s jobs for %q: %v", source.Name(), query.Keywords, err)
+						errChan <- fmt.Errorf("listing %s jobs for %q: %w", source.Name(), query.Keywords, err)
+					}
+				}()
+
+				for job := range jobChan {
+					select {
+					case <-context.Done():
+						// Drain until the listing stops on the cancelled context, so its error is
+						// sent before the error channel is closed
+						for range jobChan {
+						}
+						return
+					case matchChan <- MatchedJob{Job: job, Query: query}:
+					}
+				}
+			}()
+		}
+	}
+
+	// Close the output channels when all sources are done
 	go func() {
-		defer close(jobChan)
-		if err := scraperService.ScrapeLinkedInJobsStreaming(context, 10, jobChan, models.SearchQuery{
-			Keywords: "Frontend Developer",
-			Location: "Japan",
-			FWT:      "2,3",
-		}); err != nil {
-			log.Printf("Error scraping jobs: %v", err)
+		wg.Wait()
+		close(matchChan)
+		close(errChan)
+	}()
+
+	return matchChan, errChan
+}
+
+func listJobs(context context.Context, source JobSource, numPages int, query models.SearchQuery, jobChan chan<- models.Job, checkpoints PageCheckpoints) error {
+	resumable, ok := source.(ResumableJobSource)
+	if !ok {
+		return source.ListJobs(context, numPages, query, jobChan)
+	}
+
+	startPage := checkpoints.StartPage(source.Name(), query)
+	if startPage > 0 {
+		log.Printf("Resuming %s jobs for %q from page %d", source.Name(), query.Keywords, startPage)
+	}
+
+	return resumable.ListJobsFrom(context, startPage, numPages, query, jobChan, func(page int, jobs []models.Job) error {
+		return checkpoints.PageDone(source.Name(), query, page, jobs)
+	})
+}
+
+// ReplayJobs sends previously listed jobs ahead of the jobs coming from matchChan
+func ReplayJobs(context context.Context, replay []MatchedJob, matchChan <-chan MatchedJob) <-chan MatchedJob {
+	outChan := make(chan MatchedJob, 100)
+
+	go func() {
+		defer close(outChan)
+		for _, match := range replay {
+			select {
+			case <-context.Done():
+				return
+			case outChan <- match:
+			}
+		}
+		for match := range matchChan {
+			select {
+			case <-context.Done():
+				return
+			case outChan <- match:
+			}
 		}
 	}()
 
-	return jobChan
+	return outChan
 }
 
-func GetJobDescription(context context.Context, scraperService *Scraper, jobChan <-chan models.Job, numWorkers int) <-chan models.JobWithDescription {
+func GetJobDescription(context context.Context, sources []JobSource, jobChan <-chan models.Job, numWorkers int) <-chan models.JobWithDescription {
 	jobDescriptionChan := make(chan models.JobWithDescription, 100)
 
+	sourcesByName := make(map[string]JobSource, len(sources))
+	for _, source := range sources {
+		sourcesByName[source.Name()] = source
+	}
+
 	var wg sync.WaitGroup
 
 	// Start worker goroutines
@@ -42,17 +123,23 @@ func GetJobDescription(context context.Context, scraperService *Scraper, jobChan
 				default:
 				}
 
+				source, ok := sourcesByName[job.Source]
+				if !ok {
+					log.Printf("No source registered for job %d from %q", job.ID, job.Source)
+					continue
+				}
+
 				// Scrape job description
-				if jd, jc, err := scraperService.ScrapeJobDescriptionWithContext(context, job); err != nil {
+				if jd, err := source.FetchJobDescription(context, job); err != nil {
 					log.Printf("Error scraping jobs: %v", err)
 				} else {
+					structureCriteria(&jd)
+					if jd.Closed {
+						markClosed(&job)
+					}
 					jobDescriptionChan <- models.JobWithDescription{
-						Job: job,
-						JobDescription: models.JobDescription{
-							JobID:       job.ID,
-							Description: jd,
-							Criteria:    jc,
-						},
+						Job:            job,
+						JobDescription: jd,
 					}
 				}
 			}
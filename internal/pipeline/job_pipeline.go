@@ -2,15 +2,14 @@ package pipeline
 
 import (
 	"context"
+	"errors"
 	"fmt"
-
-	// "log"
 	"sync"
-
 	"time"
 
 	"github.com/jobs-scraper/internal/models"
 	"github.com/jobs-scraper/internal/repo"
+	"github.com/jobs-scraper/internal/utils"
 )
 
 // JobDescriptionResult represents the result of job description scraping
@@ -21,46 +20,350 @@ type JobDescriptionResult struct {
 	Error       error
 }
 
+// Repositories are the stores the pipeline reads from and writes to
+type Repositories struct {
+	Jobs            *repo.JobRepository
+	JobDescriptions *repo.JobDescriptionRepository
+	Batches         *repo.BatchRepository
+	SearchQueries   *repo.SearchQueryRepository
+	Runs            *repo.ScrapeRunRepository
+}
+
 // JobPipeline manages the job processing pipeline
 type JobPipeline struct {
-	scraperService *Scraper
-	numWorkers     int
-	rateLimit      time.Duration
+	sources       []JobSource
+	numWorkers    int
+	rateLimit     time.Duration // Minimum time between requests to a host, applied to every RateLimitedSource
+	batchSize     int
+	flushInterval time.Duration
+	refresh       RefreshPolicy
+	proxies       *utils.ProxyPool // Proxies of every ProxiedSource, nil for direct requests
+	cassette      *utils.Cassette  // Records or replays the requests of every RecordableSource
+	cache         *utils.ResponseCache
+	rates         ExchangeRates // Converts yearly salaries to one currency, empty to keep each posting's currency
+}
+
+// NewJobPipeline creates a new job processing pipeline pulling from the given sources
+func NewJobPipeline(numWorkers int, rateLimit time.Duration, sources ...JobSource) *JobPipeline {
+	if numWorkers < 1 {
+		numWorkers = 1
+	}
+
+	p := &JobPipeline{
+		numWorkers:    numWorkers,
+		rateLimit:     rateLimit,
+		batchSize:     defaultBatchSize,
+		flushInterval: defaultFlushInterval,
+	}
+	for _, source := range sources {
+		p.RegisterSource(source)
+	}
+	return p
+}
+
+// SetBatching makes the pipeline write to the database every batchSize jobs or every flushInterval
+func (p *JobPipeline) SetBatching(batchSize int, flushInterval time.Duration) {
+	if batchSize > 0 {
+		p.batchSize = batchSize
+	}
+	if flushInterval > 0 {
+		p.flushInterval = flushInterval
+	}
+}
+
+// SetRefreshPolicy decides which stored job descriptions are fetched again, for every source
+// registered before or after
+func (p *JobPipeline) SetRefreshPolicy(policy RefreshPolicy) {
+	p.refresh = policy
+	for _, source := range p.sources {
+		if refreshing, ok := source.(RefreshingSource); ok {
+			refreshing.SetRefreshPolicy(policy)
+		}
+	}
 }
 
-// NewJobPipeline creates a new job processing pipeline
-func NewJobPipeline(scraperService *Scraper, numWorkers int, rateLimit time.Duration) *JobPipeline {
-	return &JobPipeline{
-		scraperService: scraperService,
-		numWorkers:     numWorkers,
-		rateLimit:      rateLimit,
+// SetProxyPool sends the requests of every source, registered before or after, through pool
+func (p *JobPipeline) SetProxyPool(pool *utils.ProxyPool) {
+	p.proxies = pool
+	for _, source := range p.sources {
+		if proxied, ok := source.(ProxiedSource); ok {
+			proxied.SetProxyPool(pool)
+		}
 	}
 }
 
+// SetCassette records the requests of every source, registered before or after, to cassette or
+// replays them from it
+func (p *JobPipeline) SetCassette(cassette *utils.Cassette) {
+	p.cassette = cassette
+	for _, source := range p.sources {
+		if recordable, ok := source.(RecordableSource); ok {
+			recordable.UseCassette(cassette)
+		}
+	}
+}
+
+// SetResponseCache serves the responses of every source, registered before or after, from cache
+func (p *JobPipeline) SetResponseCache(cache *utils.ResponseCache) {
+	p.cache = cache
+	for _, source := range p.sources {
+		if cached, ok := source.(CachedSource); ok {
+			cached.UseResponseCache(cache)
+		}
+	}
+}
+
+// SetExchangeRates converts the yearly salary of every job to the base currency of rates
+func (p *JobPipeline) SetExchangeRates(rates ExchangeRates) {
+	p.rates = rates
+}
+
+// RegisterSource adds a job source to the pipeline
+func (p *JobPipeline) RegisterSource(source JobSource) {
+	if limited, ok := source.(RateLimitedSource); ok && p.rateLimit > 0 {
+		limited.SetRequestInterval(p.rateLimit)
+	}
+	if proxied, ok := source.(ProxiedSource); ok && p.proxies != nil {
+		proxied.SetProxyPool(p.proxies)
+	}
+	if recordable, ok := source.(RecordableSource); ok && p.cassette != nil {
+		recordable.UseCassette(p.cassette)
+	}
+	if cached, ok := source.(CachedSource); ok && p.cache != nil {
+		cached.UseResponseCache(p.cache)
+	}
+	if refreshing, ok := source.(RefreshingSource); ok {
+		refreshing.SetRefreshPolicy(p.refresh)
+	}
+	p.sources = append(p.sources, source)
+}
+
 // ProcessJobsStreaming processes jobs and job descriptions concurrently
-func (p *JobPipeline) ProcessJobsStreaming(ctx context.Context, numPages int, jobRepo *repo.JobRepository, jobDescRepo *repo.JobDescriptionRepository, params models.SearchQuery) error {
-	// Create channels for the pipeline
-	allJobs := make([]models.Job, 0, 100)
-	allJobDescriptions := make([]models.JobDescription, 0, 100)
-	var jbMu sync.Mutex
-	jobsChan := GetJobs(ctx, p.scraperService)
-	jobWithDescriptionChan := GetJobDescription(ctx, p.scraperService, jobsChan, 3)
+func (p *JobPipeline) ProcessJobsStreaming(ctx context.Context, numPages int, repos Repositories, params models.SearchQuery) error {
+	_, _, err := p.process(ctx, numPages, []models.SearchQuery{params}, repos, noCheckpoints{}, nil)
+	return err
+}
+
+// ProcessSearchProfile runs all queries of the profile under one shared rate budget, fetching each
+// job's description once no matter how many queries listed it, and records which queries matched each job.
+// The progress of the run is checkpointed so it can be continued with ResumeRun.
+func (p *JobPipeline) ProcessSearchProfile(ctx context.Context, profile models.SearchProfile, repos Repositories) error {
+	run, err := repos.Runs.CreateRun(profile)
+	if err != nil {
+		return fmt.Errorf("failed to create scrape run: %w", err)
+	}
+
+	fmt.Printf("Started scrape run %d\n", run.ID)
+	return p.processRun(ctx, run, newRunCheckpoints(run.ID, repos.Runs, nil), nil, repos)
+}
+
+// ResumeRun continues an unfinished run without fetching the pages and descriptions it already saved
+func (p *JobPipeline) ResumeRun(ctx context.Context, runID int64, repos Repositories) error {
+	run, err := repos.Runs.GetRun(runID)
+	if err != nil {
+		return err
+	}
+	if run.Status == models.RunStatusCompleted {
+		return fmt.Errorf("scrape run %d is already completed", runID)
+	}
+
+	pages, err := repos.Runs.GetPageCheckpoints(runID)
+	if err != nil {
+		return err
+	}
+
+	pendingJobs, err := repos.Runs.GetPendingJobs(runID)
+	if err != nil {
+		return err
+	}
+
+	queries := make(map[string]models.SearchQuery, len(run.Profile.Queries))
+	for _, query := range run.Profile.Queries {
+		queries[query.Key()] = query
+	}
 
-	for jobWithDescription := range jobWithDescriptionChan {
-		fmt.Printf("Received job description for job : %d\n", jobWithDescription.Job.ID)
-		jbMu.Lock()
-		allJobs = append(allJobs, jobWithDescription.Job)
-		allJobDescriptions = append(allJobDescriptions, jobWithDescription.JobDescription)
-		jbMu.Unlock()
+	pending := make([]MatchedJob, 0, len(pendingJobs))
+	for _, pendingJob := range pendingJobs {
+		pending = append(pending, MatchedJob{Job: pendingJob.Job, Query: queries[pendingJob.QueryKey]})
 	}
 
-	if err := jobRepo.SaveJobs(allJobs); err != nil {
-		return fmt.Errorf("failed to save jobs to database: %w", err)
+	if err := repos.Runs.MarkRunning(runID); err != nil {
+		return err
 	}
 
-	if err := jobDescRepo.SaveJobDescriptions(allJobDescriptions); err != nil {
-		return fmt.Errorf("failed to save job descriptions: %w", err)
+	fmt.Printf("Resuming scrape run %d with %d pending job descriptions\n", run.ID, len(pending))
+	return p.processRun(ctx, run, newRunCheckpoints(run.ID, repos.Runs, pages), pending, repos)
+}
+
+func (p *JobPipeline) processRun(ctx context.Context, run *models.ScrapeRun, checkpoints PageCheckpoints, pending []MatchedJob, repos Repositories) error {
+	savedJobs, matches, processErr := p.process(ctx, run.Profile.Pages, run.Profile.Queries, repos, checkpoints, pending)
+
+	// Record the matches of whatever was saved, even when some batches failed
+	for queryKey, jobs := range matches.ForJobs(savedJobs) {
+		query := matches.Query(queryKey)
+		fmt.Printf("Query %q in %q matched %d jobs\n", query.Keywords, query.Location, len(jobs))
+		if err := repos.SearchQueries.SaveJobMatches(query, jobs); err != nil && processErr == nil {
+			processErr = fmt.Errorf("failed to save search matches: %w", err)
+		}
 	}
 
+	// Jobs whose descriptions could not be fetched stay pending, the run is not done until a
+	// resume saves them
+	status, errMsg := models.RunStatusCompleted, ""
+	if processErr == nil {
+		pendingCount, err := repos.Runs.CountPendingJobs(run.ID)
+		if err != nil {
+			processErr = err
+		} else if pendingCount > 0 {
+			status, errMsg = models.RunStatusIncomplete, fmt.Sprintf("%d listed jobs were not saved", pendingCount)
+			fmt.Printf("Scrape run %d is incomplete, %d listed jobs were not saved, continue it with -resume %d\n", run.ID, pendingCount, run.ID)
+		}
+	}
+	if processErr != nil {
+		status, errMsg = models.RunStatusFailed, processErr.Error()
+	}
+	if err := repos.Runs.FinishRun(run.ID, status, errMsg); err != nil && processErr == nil {
+		processErr = err
+	}
+
+	if processErr != nil {
+		return fmt.Errorf("scrape run %d: %w", run.ID, processErr)
+	}
 	return nil
 }
+
+// process runs the queries and writes the jobs whose descriptions were fetched in batches,
+// returning the jobs that were saved or were already stored
+func (p *JobPipeline) process(ctx context.Context, numPages int, queries []models.SearchQuery, repos Repositories, checkpoints PageCheckpoints, pending []MatchedJob) ([]models.Job, *QueryMatches, error) {
+	matches := NewQueryMatches()
+	if len(p.sources) == 0 {
+		return nil, matches, fmt.Errorf("no job sources registered")
+	}
+
+	ctx, cancel := p.stopOnBreakerAbort(ctx)
+	defer cancel(nil)
+
+	matchChan, listErrChan := GetJobs(ctx, p.sources, numPages, queries, checkpoints)
+	if len(pending) > 0 {
+		matchChan = ReplayJobs(ctx, pending, matchChan)
+	}
+	jobsChan := DedupeJobs(ctx, matchChan, matches)
+
+	// Jobs already stored are skipped but still count as saved for matches and checkpoints. A
+	// cancelled run closes reportChan while the skip stage may still be running.
+	var (
+		skippedMu   sync.Mutex
+		skippedJobs []models.Job
+	)
+	if !p.refresh.RefetchAll {
+		jobsChan = SkipStoredJobs(ctx, jobsChan, storedJobs{repos: repos}, p.refresh, func(skipped []models.Job) {
+			skippedMu.Lock()
+			skippedJobs = append(skippedJobs, skipped...)
+			skippedMu.Unlock()
+			if err := checkpoints.JobsSaved(skipped); err != nil {
+				fmt.Printf("Failed to checkpoint skipped jobs: %v\n", err)
+			}
+		})
+	}
+	jobWithDescriptionChan := GetJobDescription(ctx, p.sources, jobsChan, p.numWorkers)
+	jobWithDescriptionChan = NormalizeSalaries(jobWithDescriptionChan, p.rates)
+	jobWithDescriptionChan = NormalizeLocations(jobWithDescriptionChan, DefaultGazetteer())
+	jobWithDescriptionChan = DetectLanguageRequirements(jobWithDescriptionChan)
+	reportChan := SinkJobs(jobWithDescriptionChan, repos.Batches, p.batchSize, p.flushInterval)
+
+	savedJobs := make([]models.Job, 0, 100)
+	failedBatches := 0
+	for report := range reportChan {
+		savedJobs = append(savedJobs, report.Saved...)
+		if err := checkpoints.JobsSaved(report.Saved); err != nil {
+			fmt.Printf("Batch %d: failed to checkpoint saved jobs: %v\n", report.Number, err)
+		}
+		if report.Err != nil {
+			failedBatches++
+			fmt.Printf("Batch %d: saved %d of %d jobs: %v\n", report.Number, len(report.Saved), report.Size, report.Err)
+			continue
+		}
+		fmt.Printf("Batch %d: saved %d jobs\n", report.Number, len(report.Saved))
+	}
+	skippedMu.Lock()
+	savedJobs = append(savedJobs, skippedJobs...)
+	skippedMu.Unlock()
+	listErr := listingError(listErrChan)
+
+	p.printBreakerSummary()
+
+	if failedBatches > 0 {
+		return savedJobs, matches, fmt.Errorf("failed to save %d batches of jobs", failedBatches)
+	}
+	if err := context.Cause(ctx); err != nil {
+		return savedJobs, matches, err
+	}
+
+	// A listing that stopped early left pages unlisted, the run is not done
+	return savedJobs, matches, listErr
+}
+
+// listingError returns the error a run fails with when listings stopped early, nil when every
+// listing finished. A layout drift is reported first, since every later run fails the same way
+// until the selector profile is updated.
+func listingError(errChan <-chan error) error {
+	var errs []error
+	for err := range errChan {
+		errs = append(errs, err)
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+
+	first := errs[0]
+	for _, err := range errs {
+		var drift *LayoutDriftError
+		if errors.As(err, &drift) {
+			first = err
+			break
+		}
+	}
+
+	if len(errs) == 1 {
+		return first
+	}
+	return fmt.Errorf("%d listings stopped early: %w", len(errs), first)
+}
+
+// circuitBreakers returns the breakers of the sources that have one
+func (p *JobPipeline) circuitBreakers() []*utils.CircuitBreaker {
+	var breakers []*utils.CircuitBreaker
+	for _, source := range p.sources {
+		if withBreaker, ok := source.(BreakerSource); ok {
+			breakers = append(breakers, withBreaker.CircuitBreaker())
+		}
+	}
+	return breakers
+}
+
+// stopOnBreakerAbort returns a context that is cancelled with the breaker error once the breaker
+// of any source gives up, so a source that is blocked stops the whole run
+func (p *JobPipeline) stopOnBreakerAbort(ctx context.Context) (context.Context, context.CancelCauseFunc) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	for _, breaker := range p.circuitBreakers() {
+		go func() {
+			select {
+			case <-breaker.Aborted():
+				cancel(breaker.Err())
+			case <-ctx.Done():
+			}
+		}()
+	}
+	return ctx, cancel
+}
+
+// printBreakerSummary prints the hosts whose breaker opened during the run
+func (p *JobPipeline) printBreakerSummary() {
+	for _, breaker := range p.circuitBreakers() {
+		for _, status := range breaker.Status() {
+			if status.Opened > 0 || status.State != utils.BreakerClosed {
+				fmt.Printf("Circuit breaker %s\n", status)
+			}
+		}
+	}
+}
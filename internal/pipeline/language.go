@@ -0,0 +1,421 @@
+package pipeline
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/jobs-scraper/internal/models"
+)
+
+// spokenLanguages are the languages a posting can ask for, by ISO 639-1 code, with the lowercase
+// names they are written as
+var spokenLanguages = []struct {
+	code  string
+	names []string
+}{
+	{"en", []string{"english", "英語"}},
+	{"ja", []string{"japanese", "nihongo", "日本語"}},
+	{"zh", []string{"chinese", "mandarin", "cantonese", "中国語"}},
+	{"ko", []string{"korean", "韓国語"}},
+	{"de", []string{"german", "deutsch", "ドイツ語"}},
+	{"fr", []string{"french", "français", "フランス語"}},
+	{"es", []string{"spanish", "español", "スペイン語"}},
+	{"pt", []string{"portuguese", "ポルトガル語"}},
+	{"it", []string{"italian", "イタリア語"}},
+	{"nl", []string{"dutch"}},
+	{"ru", []string{"russian", "ロシア語"}},
+	{"vi", []string{"vietnamese", "ベトナム語"}},
+	{"th", []string{"thai", "タイ語"}},
+	{"hi", []string{"hindi"}},
+}
+
+var (
+	languageNames       = make(map[string]string) // Lowercase name to code
+	languageNamePattern *regexp.Regexp
+)
+
+func init() {
+	var words, scripts []string
+	for _, language := range spokenLanguages {
+		for _, name := range language.names {
+			languageNames[name] = language.code
+			if utf8.RuneCountInString(name) == len(name) {
+				words = append(words, regexp.QuoteMeta(name))
+			} else {
+				scripts = append(scripts, regexp.QuoteMeta(name))
+			}
+		}
+	}
+	languageNamePattern = regexp.MustCompile(`(?i)\b(?:` + strings.Join(words, "|") + `)\b|` + strings.Join(scripts, "|"))
+}
+
+// languageLevelPatterns are the words that state a proficiency level. When several are as close to
+// a language the longest wins, so "working proficiency" is business level and not fluent.
+var languageLevelPatterns = []struct {
+	level   string
+	pattern *regexp.Regexp
+}{
+	{models.LanguageLevelNative, regexp.MustCompile(`(?i)\bnative(?:[- ]level)?\b|\bmother tongue\b|ネイティブ|母国語|母語`)},
+	{models.LanguageLevelFluent, regexp.MustCompile(`(?i)\bfluen(?:t|tly|cy)\b|\badvanced\b|\bproficien(?:t|cy)\b|\bexcellent\b|\bfull professional proficiency\b|堪能|流暢|上級`)},
+	{models.LanguageLevelBusiness, regexp.MustCompile(`(?i)\bbusiness(?:[- ]level)?\b|\bworking proficiency\b|\bprofessional working proficiency\b|ビジネス`)},
+	{models.LanguageLevelConversational, regexp.MustCompile(`(?i)\bconversational\b|\bintermediate\b|\blimited working proficiency\b|日常会話|中級`)},
+	{models.LanguageLevelBasic, regexp.MustCompile(`(?i)\bbasic\b|\bbeginner\b|\belementary\b|初級|基礎`)},
+}
+
+// cefrPattern matches CEFR levels such as B2, which only count next to a language name
+var cefrPattern = regexp.MustCompile(`\b([ABC][12])\b`)
+
+var cefrLevels = map[string]string{
+	"A1": models.LanguageLevelBasic,
+	"A2": models.LanguageLevelBasic,
+	"B1": models.LanguageLevelConversational,
+	"B2": models.LanguageLevelBusiness,
+	"C1": models.LanguageLevelFluent,
+	"C2": models.LanguageLevelNative,
+}
+
+var (
+	jlptPattern  = regexp.MustCompile(`(?i)(?:\bJLPT|日本語能力試験)\s*[（(]?\s*(?:JLPT)?\s*[)）]?\s*(?:level\s*)?N\s?([1-5])\b`)
+	jlptNPattern = regexp.MustCompile(`\bN([1-5])\s*(?:level|or above|or higher|以上|レベル|程度)`)
+	toeicPattern = regexp.MustCompile(`(?i)\bTOEIC\b\D{0,20}?(\d{3})`)
+)
+
+var jlptLevels = map[string]string{
+	"1": models.LanguageLevelFluent,
+	"2": models.LanguageLevelBusiness,
+	"3": models.LanguageLevelConversational,
+	"4": models.LanguageLevelBasic,
+	"5": models.LanguageLevelBasic,
+}
+
+var (
+	// clauseSeparator splits lines into the clauses a level and a language have to share
+	clauseSeparator = regexp.MustCompile(`[;。！？!?]|\.\s`)
+
+	// languageNegation marks clauses saying a language is not needed, e.g. "No Japanese required"
+	languageNegation = regexp.MustCompile(`(?i)\bnot\s+(?:required|necessary|needed|a\s+requirement)\b|\bno\s+\S+\s+(?:required|needed|necessary)\b|不要|不問|必要ありません|なくても`)
+
+	// languagePreference marks clauses and section headings about languages that are only a plus
+	languagePreference = regexp.MustCompile(`(?i)\bpreferred\b|\bpreferably\b|\ba plus\b|\bnice[- ]to[- ]have\b|\bbonus\b|\badvantage(?:ous)?\b|\bideally\b|歓迎|尚可|優遇|望ましい`)
+
+	// languageRequirementHeading marks section headings of required qualifications
+	languageRequirementHeading = regexp.MustCompile(`(?i)\brequire(?:d|ments)\b|\bmust\b|\bqualifications\b|必須|応募資格`)
+
+	// languageCue marks clauses where a language without a level is still asked for
+	languageCue = regexp.MustCompile(`(?i)\brequired\b|\bmust\b|\bspeak|\bskills?\b|\bability\b|\bcommunicat|必須|必要|できる方|能力|語力`)
+
+	// languageConjunction joins languages sharing one level, e.g. "fluent English and Japanese"
+	languageConjunction = regexp.MustCompile(`(?i)^\s*(?:and|or|&|,|/|と|・|及び|および|、)\s*$`)
+)
+
+// maxLevelDistance is how many bytes a level may be away from the language it applies to
+const maxLevelDistance = 60
+
+// maxHeadingLength is the longest line taken for a section heading, in runes
+const maxHeadingLength = 60
+
+// textMatch is a match of a pattern in a clause
+type textMatch struct {
+	value      string
+	start, end int
+}
+
+// detectLanguageRequirements finds the spoken languages description asks for and their levels.
+// Mentions of the same language are merged, a required mention wins over a preferred one and
+// the highest level over lower ones.
+func detectLanguageRequirements(description string) []models.LanguageRequirement {
+	byLanguage := make(map[string]models.LanguageRequirement)
+	add := func(requirement models.LanguageRequirement) {
+		current, ok := byLanguage[requirement.Language]
+		switch {
+		case !ok,
+			requirement.Required && !current.Required,
+			requirement.Required == current.Required && models.LanguageLevelRank(requirement.Level) > models.LanguageLevelRank(current.Level):
+			byLanguage[requirement.Language] = requirement
+		}
+	}
+
+	preferredSection := false
+	for _, line := range strings.Split(description, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if utf8.RuneCountInString(line) <= maxHeadingLength && !languageNamePattern.MatchString(line) {
+			switch {
+			case languagePreference.MatchString(line):
+				preferredSection = true
+			case languageRequirementHeading.MatchString(line):
+				preferredSection = false
+			}
+		}
+
+		for _, clause := range clauseSeparator.Split(line, -1) {
+			if languageNegation.MatchString(clause) {
+				continue
+			}
+			required := !preferredSection && !languagePreference.MatchString(clause)
+			for _, requirement := range clauseLanguageRequirements(clause) {
+				requirement.Required = required
+				add(requirement)
+			}
+		}
+	}
+
+	requirements := make([]models.LanguageRequirement, 0, len(byLanguage))
+	for _, requirement := range byLanguage {
+		requirements = append(requirements, requirement)
+	}
+	sort.Slice(requirements, func(i, j int) bool { return requirements[i].Language < requirements[j].Language })
+	return requirements
+}
+
+// clauseLanguageRequirements finds the languages a single clause asks for
+func clauseLanguageRequirements(clause string) []models.LanguageRequirement {
+	var requirements []models.LanguageRequirement
+	evidence := func(start, end int) string {
+		return strings.TrimSpace(clause[start:end])
+	}
+
+	// Certificates name their language and level on their own
+	for _, match := range jlptPattern.FindAllStringSubmatchIndex(clause, -1) {
+		level := jlptLevels[clause[match[2]:match[3]]]
+		requirements = append(requirements, models.LanguageRequirement{Language: "ja", Level: level, Evidence: evidence(match[0], match[1])})
+	}
+	for _, match := range toeicPattern.FindAllStringSubmatchIndex(clause, -1) {
+		score, _ := strconv.Atoi(clause[match[2]:match[3]])
+		requirements = append(requirements, models.LanguageRequirement{Language: "en", Level: toeicLevel(score), Evidence: evidence(match[0], match[1])})
+	}
+
+	mentions := findMatches(languageNamePattern, clause, func(name string) string { return languageNames[strings.ToLower(name)] })
+	if len(mentions) == 0 {
+		return requirements
+	}
+
+	levels := findLevels(clause)
+	if len(requirements) == 0 {
+		for _, match := range jlptNPattern.FindAllStringSubmatchIndex(clause, -1) {
+			levels = append(levels, textMatch{value: jlptLevels[clause[match[2]:match[3]]], start: match[0], end: match[1]})
+		}
+	}
+
+	// Each level belongs to the language mention closest to it
+	mentionLevels := make([]*textMatch, len(mentions))
+	for i := range levels {
+		closest, best := -1, maxLevelDistance+1
+		for j, mention := range mentions {
+			if distance := matchDistance(levels[i], mention); distance < best {
+				closest, best = j, distance
+			}
+		}
+		if closest < 0 {
+			continue
+		}
+		current := mentionLevels[closest]
+		if current == nil || matchDistance(levels[i], mentions[closest]) < matchDistance(*current, mentions[closest]) ||
+			(matchDistance(levels[i], mentions[closest]) == matchDistance(*current, mentions[closest]) && levels[i].end-levels[i].start > current.end-current.start) {
+			mentionLevels[closest] = &levels[i]
+		}
+	}
+
+	// Languages listed together share the level of their neighbours
+	for changed := true; changed; {
+		changed = false
+		for i := range mentions {
+			if mentionLevels[i] != nil {
+				continue
+			}
+			for _, j := range []int{i - 1, i + 1} {
+				if j < 0 || j >= len(mentions) || mentionLevels[j] == nil {
+					continue
+				}
+				first, second := mentions[min(i, j)], mentions[max(i, j)]
+				if languageConjunction.MatchString(clause[first.end:second.start]) {
+					mentionLevels[i] = mentionLevels[j]
+					changed = true
+					break
+				}
+			}
+		}
+	}
+
+	cue := languageCue.MatchString(clause)
+	for i, mention := range mentions {
+		requirement := models.LanguageRequirement{Language: mention.value}
+		start, end := mention.start, mention.end
+		if level := mentionLevels[i]; level != nil {
+			requirement.Level = level.value
+			start, end = min(start, level.start), max(end, level.end)
+		} else if !cue {
+			// "a Japanese company" asks for nothing
+			continue
+		}
+		requirement.Evidence = evidence(start, end)
+		requirements = append(requirements, requirement)
+	}
+	return requirements
+}
+
+func findLevels(clause string) []textMatch {
+	var levels []textMatch
+	for _, level := range languageLevelPatterns {
+		levels = append(levels, findMatches(level.pattern, clause, func(string) string { return level.level })...)
+	}
+	levels = append(levels, findMatches(cefrPattern, clause, func(cefr string) string { return cefrLevels[cefr] })...)
+	return levels
+}
+
+func findMatches(pattern *regexp.Regexp, text string, value func(match string) string) []textMatch {
+	var matches []textMatch
+	for _, index := range pattern.FindAllStringIndex(text, -1) {
+		matches = append(matches, textMatch{value: value(text[index[0]:index[1]]), start: index[0], end: index[1]})
+	}
+	return matches
+}
+
+// matchDistance is the number of bytes between two matches, 0 when they overlap
+func matchDistance(a, b textMatch) int {
+	switch {
+	case a.end <= b.start:
+		return b.start - a.end
+	case b.end <= a.start:
+		return a.start - b.end
+	default:
+		return 0
+	}
+}
+
+func toeicLevel(score int) string {
+	switch {
+	case score >= 860:
+		return models.LanguageLevelFluent
+	case score >= 730:
+		return models.LanguageLevelBusiness
+	case score >= 600:
+		return models.LanguageLevelConversational
+	default:
+		return models.LanguageLevelBasic
+	}
+}
+
+// latinStopwords are frequent words telling apart the languages written in Latin script
+var latinStopwords = []struct {
+	code  string
+	words map[string]bool
+}{
+	{"en", wordSet("the and to of you with for we our is are will in on as be this that")},
+	{"de", wordSet("und der die das mit für wir sie ist zu von den ein eine auf bei uns")},
+	{"fr", wordSet("le la les et des vous nous pour avec est un une dans du sur au")},
+	{"es", wordSet("el la los las y para con que una por del en es nuestro tu")},
+	{"nl", wordSet("de het en van een wij voor met je zijn op te ons")},
+	{"pt", wordSet("o os e de para com você nossa nosso uma do da em que são")},
+	{"it", wordSet("il di e per con che sono una gli le del della siamo noi")},
+}
+
+func wordSet(words string) map[string]bool {
+	set := make(map[string]bool)
+	for _, word := range strings.Fields(words) {
+		set[word] = true
+	}
+	return set
+}
+
+// minLanguageLetters is the fewest letters a text needs for its language to be detected
+const minLanguageLetters = 20
+
+// cjkWeight is how many Latin letters one kana, hanzi or hangul is counted as, so that a Japanese
+// description with English tool names is still Japanese
+const cjkWeight = 3
+
+// detectTextLanguage returns the ISO 639-1 code of the language text is mostly written in, from
+// its script and, for Latin script, its most frequent words. It returns "" for short texts.
+func detectTextLanguage(text string) string {
+	var latin, kana, han, hangul, cyrillic, thai int
+	for _, r := range text {
+		switch {
+		case unicode.In(r, unicode.Hiragana, unicode.Katakana):
+			kana++
+		case unicode.Is(unicode.Han, r):
+			han++
+		case unicode.Is(unicode.Hangul, r):
+			hangul++
+		case unicode.Is(unicode.Cyrillic, r):
+			cyrillic++
+		case unicode.Is(unicode.Thai, r):
+			thai++
+		case unicode.Is(unicode.Latin, r):
+			latin++
+		}
+	}
+
+	// Han without kana is Chinese
+	hanCode := "ja"
+	if kana == 0 {
+		hanCode = "zh"
+	}
+
+	scripts := []struct {
+		code    string
+		letters int
+	}{
+		{hanCode, (kana + han) * cjkWeight},
+		{"ko", hangul * cjkWeight},
+		{"ru", cyrillic},
+		{"th", thai},
+		{"latin", latin},
+	}
+
+	best := scripts[0]
+	for _, script := range scripts[1:] {
+		if script.letters > best.letters {
+			best = script
+		}
+	}
+	if best.letters < minLanguageLetters {
+		return ""
+	}
+	if best.code != "latin" {
+		return best.code
+	}
+
+	counts := make([]int, len(latinStopwords))
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool { return !unicode.IsLetter(r) }) {
+		for i, language := range latinStopwords {
+			if language.words[word] {
+				counts[i]++
+			}
+		}
+	}
+	code, most := "", 0
+	for i, count := range counts {
+		if count > most {
+			code, most = latinStopwords[i].code, count
+		}
+	}
+	return code
+}
+
+// DetectLanguages sets the language jd is written in and the spoken languages it asks for
+func DetectLanguages(jd *models.JobDescription) {
+	jd.Language = detectTextLanguage(jd.Description)
+	jd.LanguageRequirements = detectLanguageRequirements(jd.Description)
+}
+
+// DetectLanguageRequirements detects the languages of every description on its way to the database
+func DetectLanguageRequirements(jobChan <-chan models.JobWithDescription) <-chan models.JobWithDescription {
+	outChan := make(chan models.JobWithDescription, 100)
+
+	go func() {
+		defer close(outChan)
+		for item := range jobChan {
+			DetectLanguages(&item.JobDescription)
+			outChan <- item
+		}
+	}()
+
+	return outChan
+}
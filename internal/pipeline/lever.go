@@ -0,0 +1,252 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/jobs-scraper/internal/models"
+	"github.com/jobs-scraper/internal/utils"
+)
+
+const leverBaseURL = "https://api.lever.co/v0/postings"
+
+type LeverConfig struct {
+	Companies []string          // Lever company slugs, e.g. "netflix" for jobs.lever.co/netflix
+	BaseURL   string            // Postings API base URL, overridable for tests
+	Retry     utils.RetryConfig // Retry settings for API requests
+}
+
+// LeverSource pulls postings from the public Lever postings API
+type LeverSource struct {
+	*sourceClient
+
+	config       LeverConfig
+	descriptions *descriptionCache
+}
+
+type leverPosting struct {
+	ID         string `json:"id"`
+	Text       string `json:"text"`
+	HostedURL  string `json:"hostedUrl"`
+	Categories struct {
+		Commitment string `json:"commitment"`
+		Department string `json:"department"`
+		Location   string `json:"location"`
+		Team       string `json:"team"`
+	} `json:"categories"`
+	Description string `json:"description"`
+	Lists       []struct {
+		Text    string `json:"text"`
+		Content string `json:"content"`
+	} `json:"lists"`
+	Additional    string `json:"additional"`
+	WorkplaceType string `json:"workplaceType"` // "on-site", "hybrid", "remote" or "unspecified"
+	SalaryRange   *struct {
+		Min      float64 `json:"min"`
+		Max      float64 `json:"max"`
+		Currency string  `json:"currency"`
+		Interval string  `json:"interval"` // e.g. "per-year-salary", "per-hour-wage"
+	} `json:"salaryRange"`
+}
+
+func NewLeverSource(config LeverConfig) *LeverSource {
+	if config.BaseURL == "" {
+		config.BaseURL = leverBaseURL
+	}
+	config.Retry = withRetryDefaults(config.Retry)
+
+	return &LeverSource{
+		sourceClient: newSourceClient(config.Retry, apiSourceTimeout, apiSourceLimits, utils.BreakerConfig{}),
+		config:       config,
+		descriptions: newDescriptionCache(),
+	}
+}
+
+// Name returns the Lever source name
+func (l *LeverSource) Name() string {
+	return models.SourceLever
+}
+
+// ListJobs lists the postings of every configured company whose title matches the search keywords.
+// The postings API is not paginated, so numPages is ignored.
+func (l *LeverSource) ListJobs(ctx context.Context, numPages int, params models.SearchQuery, jobChan chan<- models.Job) error {
+	return l.ListJobsFrom(ctx, 0, numPages, params, jobChan, nil)
+}
+
+// ListJobsFrom implements ResumableJobSource, each configured company is one page
+func (l *LeverSource) ListJobsFrom(ctx context.Context, startPage, numPages int, params models.SearchQuery, jobChan chan<- models.Job, pageDone func(page int, jobs []models.Job) error) error {
+	for page := startPage; page < len(l.config.Companies); page++ {
+		company := l.config.Companies[page]
+		var postings []leverPosting
+		if err := l.getJSON(ctx, l.companyURL(company)+"?mode=json", &postings); err != nil {
+			return fmt.Errorf("error fetching lever postings for %s: %w", company, err)
+		}
+
+		var jobs []models.Job
+		for _, posting := range postings {
+			if !matchesKeywords(posting.Text, params.Keywords) {
+				continue
+			}
+
+			job, jd, err := leverJob(company, posting)
+			if err != nil {
+				return err
+			}
+			l.descriptions.put(jd)
+			jobs = append(jobs, job)
+		}
+
+		if pageDone != nil {
+			if err := pageDone(page, jobs); err != nil {
+				return fmt.Errorf("error checkpointing lever postings for %s: %w", company, err)
+			}
+		}
+
+		for _, job := range jobs {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case jobChan <- job:
+			}
+		}
+	}
+
+	return nil
+}
+
+// FetchJobDescription returns the description that came with the listing. Jobs listed by an
+// earlier process of a resumed run are fetched again by the posting ID at the end of their link.
+func (l *LeverSource) FetchJobDescription(ctx context.Context, job models.Job) (models.JobDescription, error) {
+	if l.descriptions.has(job) {
+		return l.descriptions.get(job)
+	}
+
+	jobURL, err := url.Parse(job.JobLink)
+	if err != nil || strings.Trim(jobURL.Path, "/") == "" {
+		return models.JobDescription{}, fmt.Errorf("lever job %d was not listed by this source", job.ID)
+	}
+	postingID := path.Base(jobURL.Path)
+
+	var posting leverPosting
+	if err := l.getJSON(ctx, l.companyURL(job.Company)+"/"+url.PathEscape(postingID), &posting); err != nil {
+		return models.JobDescription{}, err
+	}
+
+	_, jd, err := leverJob(job.Company, posting)
+	if err != nil {
+		return models.JobDescription{}, err
+	}
+	if jd.Description == "" {
+		return models.JobDescription{}, fmt.Errorf("job description not found")
+	}
+	return jd, nil
+}
+
+func (l *LeverSource) companyURL(company string) string {
+	return fmt.Sprintf("%s/%s", strings.TrimRight(l.config.BaseURL, "/"), url.PathEscape(company))
+}
+
+// leverJob maps a posting of company to a job and its description
+func leverJob(company string, posting leverPosting) (models.Job, models.JobDescription, error) {
+	job := models.Job{
+		ID:            syntheticJobID(models.SourceLever, posting.ID),
+		Source:        models.SourceLever,
+		Title:         truncate(strings.TrimSpace(posting.Text), jobTextLength),
+		Company:       truncate(company, jobTextLength),
+		CompanyLink:   "https://jobs.lever.co/" + company,
+		Location:      truncate(strings.TrimSpace(posting.Categories.Location), jobTextLength),
+		JobLink:       posting.HostedURL,
+		WorkplaceType: leverWorkplaceType(posting.WorkplaceType),
+	}
+	job.Remote = job.WorkplaceType == models.WorkplaceRemote
+
+	if posting.SalaryRange != nil {
+		job.SalaryMin = posting.SalaryRange.Min
+		job.SalaryMax = posting.SalaryRange.Max
+		job.SalaryCurrency = strings.ToUpper(posting.SalaryRange.Currency)
+		job.SalaryPeriod = leverSalaryPeriod(posting.SalaryRange.Interval)
+	}
+
+	description, err := parseHTMLFragment(leverDescriptionHTML(posting))
+	if err != nil {
+		return job, models.JobDescription{}, fmt.Errorf("error parsing lever posting %s: %w", posting.ID, err)
+	}
+
+	return job, models.JobDescription{
+		JobID:               job.ID,
+		Source:              models.SourceLever,
+		Description:         description.Text,
+		DescriptionMarkdown: description.Markdown,
+		DescriptionHTML:     description.HTML,
+		Criteria:            leverCriteria(posting, job),
+	}, nil
+}
+
+// leverDescriptionHTML joins the description, the titled lists and the closing text of a posting
+func leverDescriptionHTML(posting leverPosting) string {
+	var b strings.Builder
+	b.WriteString(posting.Description)
+	for _, list := range posting.Lists {
+		fmt.Fprintf(&b, "<h3>%s</h3><ul>%s</ul>", list.Text, list.Content)
+	}
+	b.WriteString(posting.Additional)
+	return b.String()
+}
+
+func leverCriteria(posting leverPosting, job models.Job) map[string]string {
+	criteria := make(map[string]string)
+
+	if posting.Categories.Commitment != "" {
+		criteria["Commitment"] = posting.Categories.Commitment
+	}
+	if posting.Categories.Department != "" {
+		criteria["Department"] = posting.Categories.Department
+	}
+	if posting.Categories.Team != "" {
+		criteria["Team"] = posting.Categories.Team
+	}
+	if job.Location != "" {
+		criteria["Location"] = job.Location
+	}
+	if job.WorkplaceType != "" {
+		criteria["Workplace type"] = job.WorkplaceType
+	}
+	if compensation := formatSalary(job); compensation != "" {
+		criteria["Compensation"] = compensation
+	}
+
+	return criteria
+}
+
+func leverWorkplaceType(workplaceType string) string {
+	switch workplaceType {
+	case "on-site":
+		return models.WorkplaceOnsite
+	case "hybrid":
+		return models.WorkplaceHybrid
+	case "remote":
+		return models.WorkplaceRemote
+	default:
+		return ""
+	}
+}
+
+func leverSalaryPeriod(interval string) string {
+	switch interval {
+	case "per-hour-wage":
+		return models.SalaryPeriodHour
+	case "per-day-wage":
+		return models.SalaryPeriodDay
+	case "per-week-salary":
+		return models.SalaryPeriodWeek
+	case "per-month-salary":
+		return models.SalaryPeriodMonth
+	case "per-year-salary":
+		return models.SalaryPeriodYear
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,333 @@
+package pipeline
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/jobs-scraper/internal/models"
+)
+
+//go:embed locations/gazetteer.json
+var defaultGazetteerJSON []byte
+
+// Location is a posting location resolved against the gazetteer
+type Location struct {
+	CountryCode string // ISO 3166-1 alpha-2 code
+	Region      string // State or prefecture
+	City        string
+}
+
+type gazetteerCountry struct {
+	Code    string   `json:"code"`
+	Name    string   `json:"name"`
+	Aliases []string `json:"aliases"`
+}
+
+type gazetteerPlace struct {
+	Country string   `json:"country"`
+	Region  string   `json:"region"`
+	Name    string   `json:"name"`
+	Aliases []string `json:"aliases"`
+}
+
+// Gazetteer resolves location text such as "Tokyo, Tokyo, Japan" or "Shibuya-ku" to a country,
+// region and city
+type Gazetteer struct {
+	countries map[string][]Location
+	regions   map[string][]Location
+	cities    map[string][]Location
+}
+
+var defaultGazetteer = sync.OnceValue(func() *Gazetteer {
+	gazetteer, err := parseGazetteer(defaultGazetteerJSON)
+	if err != nil {
+		panic(fmt.Sprintf("invalid default gazetteer: %v", err))
+	}
+	return gazetteer
+})
+
+// DefaultGazetteer returns the gazetteer shipped with the scraper
+func DefaultGazetteer() *Gazetteer {
+	return defaultGazetteer()
+}
+
+func parseGazetteer(data []byte) (*Gazetteer, error) {
+	var file struct {
+		Countries []gazetteerCountry `json:"countries"`
+		Regions   []gazetteerPlace   `json:"regions"`
+		Cities    []gazetteerPlace   `json:"cities"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("error decoding gazetteer: %w", err)
+	}
+
+	g := &Gazetteer{
+		countries: make(map[string][]Location),
+		regions:   make(map[string][]Location),
+		cities:    make(map[string][]Location),
+	}
+	known := make(map[string]bool)
+	for _, country := range file.Countries {
+		if len(country.Code) != 2 {
+			return nil, fmt.Errorf("gazetteer country %q has an invalid code %q", country.Name, country.Code)
+		}
+		known[country.Code] = true
+		addPlace(g.countries, Location{CountryCode: country.Code}, country.Name, country.Aliases)
+	}
+	for _, region := range file.Regions {
+		if !known[region.Country] {
+			return nil, fmt.Errorf("gazetteer region %q has an unknown country %q", region.Name, region.Country)
+		}
+		addPlace(g.regions, Location{CountryCode: region.Country, Region: region.Name}, region.Name, region.Aliases)
+	}
+	for _, city := range file.Cities {
+		if !known[city.Country] {
+			return nil, fmt.Errorf("gazetteer city %q has an unknown country %q", city.Name, city.Country)
+		}
+		addPlace(g.cities, Location{CountryCode: city.Country, Region: city.Region, City: city.Name}, city.Name, city.Aliases)
+	}
+	return g, nil
+}
+
+func addPlace(index map[string][]Location, location Location, name string, aliases []string) {
+	for _, alias := range append([]string{name}, aliases...) {
+		key := normalizePlaceName(alias)
+		index[key] = append(index[key], location)
+	}
+}
+
+func normalizePlaceName(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+// Suffixes that mark a place as a region or a city, e.g. "Osaka Prefecture" or "Shibuya-ku"
+var (
+	regionSuffixes = []string{" prefecture", " metropolis", " state", "-to", "-fu", "-ken"}
+	citySuffixes   = []string{" city", " ward", "-shi", "-ku"}
+)
+
+// locationAlternatives separates the locations of postings listed in several places, the first
+// one that resolves is used
+var locationAlternatives = regexp.MustCompile(`;| / |\||\s+or\s+`)
+
+// workplaceInLocation matches the workplace hints inside location text, e.g. "Japan (Remote)"
+var workplaceInLocation = regexp.MustCompile(`(?i)\((?:remote|hybrid|on-?site)\)|\b(?:remote|hybrid|on-?site|anywhere|worldwide)\b|リモート|在宅`)
+
+// Resolve finds the country, region and city of location. Parts are read from the most general,
+// the last, to the most specific, each one narrowing down the next. The first part prefers a
+// city and the others a region, so "Tokyo, Japan" is the city and "Tokyo, Tokyo, Japan" both.
+func (g *Gazetteer) Resolve(location string) Location {
+	for _, alternative := range locationAlternatives.Split(location, -1) {
+		if resolved := g.resolveParts(alternative); resolved != (Location{}) {
+			return resolved
+		}
+	}
+	return Location{}
+}
+
+func (g *Gazetteer) resolveParts(text string) Location {
+	text = workplaceInLocation.ReplaceAllString(text, "")
+
+	var parts []string
+	for _, part := range strings.FieldsFunc(text, func(r rune) bool { return r == ',' || r == '、' || r == '，' }) {
+		for _, part := range strings.Split(part, " - ") {
+			if part = normalizePlaceName(part); part != "" {
+				parts = append(parts, part)
+			}
+		}
+	}
+
+	var resolved Location
+	for i := len(parts) - 1; i >= 0; i-- {
+		kinds := []string{"country", "region", "city"}
+		if i == 0 {
+			kinds = []string{"country", "city", "region"}
+		}
+		for _, kind := range kinds {
+			if found, ok := g.match(kind, parts[i], resolved); ok {
+				resolved = found
+				break
+			}
+		}
+	}
+
+	if resolved.City == "" && len(parts) > 0 {
+		if found, ok := g.matchContained(parts[0], resolved); ok {
+			resolved = found
+		}
+	}
+	return resolved
+}
+
+// match looks part up as a place of kind that lies within the parts resolved so far and is not
+// already known
+func (g *Gazetteer) match(kind, part string, within Location) (Location, bool) {
+	var index map[string][]Location
+	var suffixes, otherSuffixes []string
+	switch kind {
+	case "country":
+		if within.CountryCode != "" {
+			return within, false
+		}
+		index = g.countries
+	case "region":
+		if within.Region != "" {
+			return within, false
+		}
+		index, suffixes, otherSuffixes = g.regions, regionSuffixes, citySuffixes
+	case "city":
+		if within.City != "" {
+			return within, false
+		}
+		index, suffixes, otherSuffixes = g.cities, citySuffixes, regionSuffixes
+	}
+
+	names := []string{part}
+	for _, suffix := range suffixes {
+		if name, ok := strings.CutSuffix(part, suffix); ok && name != "" {
+			names = append(names, name)
+		}
+	}
+	for _, suffix := range otherSuffixes {
+		// "Osaka Prefecture" is not the city of Osaka
+		if strings.HasSuffix(part, suffix) {
+			names = names[1:]
+			break
+		}
+	}
+
+	for _, name := range names {
+		for _, candidate := range index[name] {
+			if within.CountryCode != "" && candidate.CountryCode != within.CountryCode {
+				continue
+			}
+			if within.Region != "" && candidate.Region != "" && candidate.Region != within.Region {
+				continue
+			}
+			return candidate, true
+		}
+	}
+	return within, false
+}
+
+// matchContained finds the longest city or region name written inside part, for addresses
+// written without separators such as "東京都渋谷区"
+func (g *Gazetteer) matchContained(part string, within Location) (Location, bool) {
+	if utf8.RuneCountInString(part) == len(part) {
+		return within, false
+	}
+
+	for _, index := range []map[string][]Location{g.cities, g.regions} {
+		best, bestLength := within, 0
+		for name, candidates := range index {
+			if len(name) <= bestLength || utf8.RuneCountInString(name) == len(name) || !strings.Contains(part, name) {
+				continue
+			}
+			for _, candidate := range candidates {
+				if within.CountryCode == "" || candidate.CountryCode == within.CountryCode {
+					best, bestLength = candidate, len(name)
+					break
+				}
+			}
+		}
+		if bestLength > 0 {
+			return best, true
+		}
+	}
+	return within, false
+}
+
+// workplacePatterns are phrases saying how a posting is worked, from the most to the least
+// specific. A hybrid role often also mentions days on site and remote work.
+var workplacePatterns = []struct {
+	workplaceType string
+	pattern       *regexp.Regexp
+}{
+	{models.WorkplaceHybrid, regexp.MustCompile(`(?i)\bhybrid\b|\d\s*days?\s*(?:a|per)\s*week\s*(?:in|at)\s*(?:the\s*)?office|ハイブリッド|週\s*\d\s*日(?:程度)?(?:の)?出社|一部リモート|リモート併用`)},
+	{models.WorkplaceRemote, regexp.MustCompile(`(?i)\b(?:fully|100%|full[- ]time)\s*remote\b|\bremote[- ]first\b|\bremote\s+(?:position|role|job|opportunity)\b|\(remote\)|\bwork\s+from\s+anywhere\b|\bremote\s*(?:work\s*)?(?:is\s*)?(?:ok|possible|allowed|available)\b|フルリモート|完全リモート|完全在宅|リモートワーク可|在宅勤務可`)},
+	{models.WorkplaceOnsite, regexp.MustCompile(`(?i)\bon-?site\b|\bin[- ]office\b|\boffice[- ]based\b|\bno\s+remote\b|\bnot\s+(?:a\s+)?remote\b|出社必須|リモート不可|在宅不可|常駐`)},
+}
+
+// cardWorkplacePatterns are the shorter hints of card titles and locations, e.g. "Japan (Remote)"
+var cardWorkplacePatterns = []struct {
+	workplaceType string
+	pattern       *regexp.Regexp
+}{
+	{models.WorkplaceHybrid, regexp.MustCompile(`(?i)\bhybrid\b|ハイブリッド`)},
+	{models.WorkplaceRemote, regexp.MustCompile(`(?i)\bremote\b|\banywhere\b|リモート|在宅`)},
+	{models.WorkplaceOnsite, regexp.MustCompile(`(?i)\bon-?site\b|\bin[- ]office\b|出社`)},
+}
+
+// workTypeFilterValues maps the LinkedIn f_WT filter values to workplace types
+var workTypeFilterValues = map[string]string{
+	"1": models.WorkplaceOnsite,
+	"2": models.WorkplaceRemote,
+	"3": models.WorkplaceHybrid,
+}
+
+// classifyWorkplace decides whether job is onsite, hybrid or remote. A type given by the source
+// wins, then the card text, then a work type filter that allows a single type and finally the
+// description. Hints outside the types the filter allows are ignored.
+func classifyWorkplace(job models.Job, jd models.JobDescription) string {
+	if job.WorkplaceType != "" {
+		return job.WorkplaceType
+	}
+
+	allowed := make(map[string]bool)
+	for _, value := range strings.Split(job.WorkTypeFilter, ",") {
+		if workplaceType, ok := workTypeFilterValues[strings.TrimSpace(value)]; ok {
+			allowed[workplaceType] = true
+		}
+	}
+	isAllowed := func(workplaceType string) bool {
+		return len(allowed) == 0 || allowed[workplaceType]
+	}
+
+	for _, hint := range cardWorkplacePatterns {
+		if hint.pattern.MatchString(job.Title+" "+job.Location) && isAllowed(hint.workplaceType) {
+			return hint.workplaceType
+		}
+	}
+
+	if len(allowed) == 1 {
+		for workplaceType := range allowed {
+			return workplaceType
+		}
+	}
+
+	for _, hint := range workplacePatterns {
+		if hint.pattern.MatchString(jd.Description) && isAllowed(hint.workplaceType) {
+			return hint.workplaceType
+		}
+	}
+	return ""
+}
+
+// normalizeLocation resolves the location of job and classifies how it is worked
+func normalizeLocation(job *models.Job, jd models.JobDescription, gazetteer *Gazetteer) {
+	location := gazetteer.Resolve(job.Location)
+	job.CountryCode, job.Region, job.City = location.CountryCode, location.Region, location.City
+
+	job.WorkplaceType = classifyWorkplace(*job, jd)
+	job.Remote = job.Remote || job.WorkplaceType == models.WorkplaceRemote
+}
+
+// NormalizeLocations resolves the location and workplace type of every job on its way to the database
+func NormalizeLocations(jobChan <-chan models.JobWithDescription, gazetteer *Gazetteer) <-chan models.JobWithDescription {
+	outChan := make(chan models.JobWithDescription, 100)
+
+	go func() {
+		defer close(outChan)
+		for item := range jobChan {
+			normalizeLocation(&item.Job, item.JobDescription, gazetteer)
+			outChan <- item
+		}
+	}()
+
+	return outChan
+}
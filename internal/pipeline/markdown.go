@@ -0,0 +1,422 @@
+package pipeline
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"unicode"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+// formattedDescription is a job description as plain text for prompts, as Markdown and as
+// sanitized HTML
+type formattedDescription struct {
+	Text     string
+	Markdown string
+	HTML     string
+}
+
+// formatDescription converts the contents of selection into every description format
+func formatDescription(selection *goquery.Selection) formattedDescription {
+	var markdown, sanitized []string
+	for _, node := range selection.Nodes {
+		if text := htmlToMarkdown(node); text != "" {
+			markdown = append(markdown, text)
+		}
+		if markup := sanitizeHTML(node); markup != "" {
+			sanitized = append(sanitized, markup)
+		}
+	}
+
+	return formattedDescription{
+		Text:     strings.TrimSpace(parseHTMLContent(selection)),
+		Markdown: strings.Join(markdown, "\n\n"),
+		HTML:     strings.Join(sanitized, "\n"),
+	}
+}
+
+// blockElements start a new Markdown block
+var blockElements = map[string]bool{
+	"p": true, "div": true, "section": true, "article": true, "header": true, "footer": true, "main": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"ul": true, "ol": true, "li": true, "blockquote": true, "pre": true, "table": true, "hr": true,
+}
+
+// ignoredElements are dropped together with their content
+var ignoredElements = map[string]bool{
+	"script": true, "style": true, "noscript": true, "template": true, "iframe": true, "head": true,
+}
+
+// htmlToMarkdown converts the children of n into Markdown
+func htmlToMarkdown(n *html.Node) string {
+	return strings.Join(markdownBlocks(n), "\n\n")
+}
+
+// markdownBlocks converts the children of n into Markdown blocks, inline content between block
+// elements becomes paragraphs
+func markdownBlocks(n *html.Node) []string {
+	var blocks []string
+	var inline strings.Builder
+
+	flush := func() {
+		if text := markdownParagraphs(inline.String()); text != "" {
+			blocks = append(blocks, text)
+		}
+		inline.Reset()
+	}
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && (blockElements[c.Data] || containsBlock(c)) {
+			flush()
+			if block := markdownBlock(c); block != "" {
+				blocks = append(blocks, block)
+			}
+			continue
+		}
+		inline.WriteString(markdownInline(c))
+	}
+	flush()
+
+	return blocks
+}
+
+func markdownBlock(n *html.Node) string {
+	switch n.Data {
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		text := singleLine(markdownParagraphs(markdownInlineChildren(n)))
+		if text == "" {
+			return ""
+		}
+		return strings.Repeat("#", int(n.Data[1]-'0')) + " " + text
+	case "ul":
+		return markdownList(n, false)
+	case "ol":
+		return markdownList(n, true)
+	case "blockquote":
+		lines := strings.Split(htmlToMarkdown(n), "\n")
+		for i, line := range lines {
+			lines[i] = strings.TrimRight("> "+line, " ")
+		}
+		return strings.Join(lines, "\n")
+	case "pre":
+		text := strings.Trim(textContent(n), "\n")
+		if text == "" {
+			return ""
+		}
+		return "```\n" + text + "\n```"
+	case "table":
+		return markdownTable(n)
+	case "hr":
+		return "---"
+	default:
+		return htmlToMarkdown(n)
+	}
+}
+
+// markdownList converts the items of a list, nested lists are indented under their item
+func markdownList(list *html.Node, ordered bool) string {
+	var items []string
+	number := 1
+
+	for c := list.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode {
+			continue
+		}
+
+		switch c.Data {
+		case "li":
+			marker := "- "
+			if ordered {
+				marker = fmt.Sprintf("%d. ", number)
+				number++
+			}
+			body := strings.Join(markdownBlocks(c), "\n")
+			if body == "" {
+				continue
+			}
+			items = append(items, marker+indent(body, len(marker)))
+		case "ul", "ol":
+			// A list directly inside a list belongs to the previous item
+			nested := markdownList(c, c.Data == "ol")
+			if nested == "" {
+				continue
+			}
+			if len(items) == 0 {
+				items = append(items, nested)
+				continue
+			}
+			items[len(items)-1] += "\n  " + indent(nested, 2)
+		}
+	}
+
+	return strings.Join(items, "\n")
+}
+
+// markdownTable converts a table into a pipe table whose first row is the header
+func markdownTable(table *html.Node) string {
+	var rows [][]string
+	columns := 0
+
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type != html.ElementNode || c.Data == "table" {
+				continue
+			}
+			if c.Data != "tr" {
+				walk(c)
+				continue
+			}
+
+			var row []string
+			for cell := c.FirstChild; cell != nil; cell = cell.NextSibling {
+				if cell.Type == html.ElementNode && (cell.Data == "th" || cell.Data == "td") {
+					text := singleLine(markdownParagraphs(markdownInlineChildren(cell)))
+					row = append(row, strings.ReplaceAll(text, "|", `\|`))
+				}
+			}
+			if len(row) > 0 {
+				rows = append(rows, row)
+				columns = max(columns, len(row))
+			}
+		}
+	}
+	walk(table)
+
+	if len(rows) == 0 {
+		return ""
+	}
+
+	lines := make([]string, 0, len(rows)+1)
+	for i, row := range rows {
+		for len(row) < columns {
+			row = append(row, "")
+		}
+		lines = append(lines, "| "+strings.Join(row, " | ")+" |")
+		if i == 0 {
+			lines = append(lines, "|"+strings.Repeat(" --- |", columns))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// markdownInline converts an inline node, line breaks are kept as "\n" until
+// markdownParagraphs lays out the paragraph
+func markdownInline(n *html.Node) string {
+	switch n.Type {
+	case html.TextNode:
+		return escapeMarkdown(collapseWhitespace(n.Data))
+	case html.ElementNode:
+	default:
+		return ""
+	}
+
+	if ignoredElements[n.Data] {
+		return ""
+	}
+
+	switch n.Data {
+	case "br":
+		return "\n"
+	case "strong", "b":
+		return wrapInline(markdownInlineChildren(n), "**", "**")
+	case "em", "i":
+		return wrapInline(markdownInlineChildren(n), "*", "*")
+	case "code":
+		return wrapInline(collapseWhitespace(textContent(n)), "`", "`")
+	case "a":
+		text := markdownInlineChildren(n)
+		href, ok := safeHref(attr(n, "href"))
+		if !ok || strings.TrimSpace(text) == "" {
+			return text
+		}
+		return wrapInline(strings.ReplaceAll(text, "\n", " "), "[", "]("+href+")")
+	default:
+		return markdownInlineChildren(n)
+	}
+}
+
+func markdownInlineChildren(n *html.Node) string {
+	var b strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		b.WriteString(markdownInline(c))
+	}
+	return b.String()
+}
+
+// wrapInline surrounds text with the open and close markers, keeping the spaces around text
+// outside of them since "** bold **" is not bold in Markdown
+func wrapInline(text, open, close string) string {
+	trimmed := strings.TrimSpace(text)
+	if trimmed == "" {
+		return text
+	}
+
+	leading := text[:strings.Index(text, trimmed)]
+	trailing := text[len(leading)+len(trimmed):]
+	return leading + open + trimmed + close + trailing
+}
+
+// markdownParagraphs lays out inline content: single line breaks become hard breaks and empty
+// lines separate paragraphs
+func markdownParagraphs(inline string) string {
+	var paragraphs, lines []string
+	for _, line := range strings.Split(inline, "\n") {
+		line = strings.Join(strings.Fields(line), " ")
+		if line == "" {
+			if len(lines) > 0 {
+				paragraphs = append(paragraphs, strings.Join(lines, "  \n"))
+				lines = nil
+			}
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) > 0 {
+		paragraphs = append(paragraphs, strings.Join(lines, "  \n"))
+	}
+
+	return strings.Join(paragraphs, "\n\n")
+}
+
+// singleLine joins the lines of text with spaces, for headings, table cells and link texts
+func singleLine(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// indent prefixes every line of text but the first with width spaces
+func indent(text string, width int) string {
+	return strings.ReplaceAll(text, "\n", "\n"+strings.Repeat(" ", width))
+}
+
+// collapseWhitespace turns runs of whitespace into one space, keeping a space at either end so
+// inline siblings stay separated
+func collapseWhitespace(text string) string {
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		if text == "" {
+			return ""
+		}
+		return " "
+	}
+
+	collapsed := strings.Join(fields, " ")
+	if strings.TrimLeftFunc(text, unicode.IsSpace) != text {
+		collapsed = " " + collapsed
+	}
+	if strings.TrimRightFunc(text, unicode.IsSpace) != text {
+		collapsed += " "
+	}
+	return collapsed
+}
+
+var markdownEscaper = strings.NewReplacer(`\`, `\\`, "*", `\*`, "`", "\\`", "[", `\[`, "]", `\]`)
+
+// escapeMarkdown keeps text from being read as emphasis, code or links
+func escapeMarkdown(text string) string {
+	return markdownEscaper.Replace(text)
+}
+
+// containsBlock reports whether an inline element wraps block elements, e.g. a span around a list
+func containsBlock(n *html.Node) bool {
+	if ignoredElements[n.Data] {
+		return false
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && (blockElements[c.Data] || containsBlock(c)) {
+			return true
+		}
+	}
+	return false
+}
+
+func textContent(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var b strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		b.WriteString(textContent(c))
+	}
+	return b.String()
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// safeHref returns href when it is an absolute http, https or mailto link
+func safeHref(href string) (string, bool) {
+	parsed, err := url.Parse(strings.TrimSpace(href))
+	if err != nil {
+		return "", false
+	}
+	switch strings.ToLower(parsed.Scheme) {
+	case "http", "https", "mailto":
+		return parsed.String(), true
+	}
+	return "", false
+}
+
+// sanitizedElements are the elements kept by sanitizeHTML, others are replaced by their content
+var sanitizedElements = map[string]bool{
+	"p": true, "div": true, "br": true, "hr": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"ul": true, "ol": true, "li": true, "blockquote": true, "pre": true, "code": true,
+	"strong": true, "b": true, "em": true, "i": true, "u": true, "a": true,
+	"table": true, "thead": true, "tbody": true, "tfoot": true, "tr": true, "th": true, "td": true,
+}
+
+// sanitizeHTML renders the children of n with only the sanitizedElements and no attributes but
+// the href of links
+func sanitizeHTML(n *html.Node) string {
+	var b strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		writeSanitized(&b, c)
+	}
+	return strings.TrimSpace(b.String())
+}
+
+func writeSanitized(b *strings.Builder, n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		b.WriteString(html.EscapeString(n.Data))
+		return
+	case html.ElementNode:
+	default:
+		return
+	}
+
+	if ignoredElements[n.Data] {
+		return
+	}
+	href, safeLink := safeHref(attr(n, "href"))
+	// Links without a safe target are replaced by their content as well
+	if !sanitizedElements[n.Data] || (n.Data == "a" && !safeLink) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			writeSanitized(b, c)
+		}
+		return
+	}
+
+	b.WriteString("<" + n.Data)
+	if n.Data == "a" {
+		b.WriteString(` href="` + html.EscapeString(href) + `"`)
+	}
+	b.WriteString(">")
+
+	if n.Data == "br" || n.Data == "hr" {
+		return
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		writeSanitized(b, c)
+	}
+	b.WriteString("</" + n.Data + ">")
+}
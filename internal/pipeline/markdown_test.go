@@ -0,0 +1,59 @@
+package pipeline
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite the golden files of the description tests")
+
+// TestFormatDescriptionGolden formats every testdata/descriptions/<name>.input.html fragment and
+// compares the plain text, Markdown and sanitized HTML to the <name>.golden.* files
+func TestFormatDescriptionGolden(t *testing.T) {
+	inputs, err := filepath.Glob(filepath.Join("testdata", "descriptions", "*.input.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(inputs) == 0 {
+		t.Fatal("no description fixtures found")
+	}
+
+	for _, input := range inputs {
+		name := strings.TrimSuffix(filepath.Base(input), ".input.html")
+		t.Run(name, func(t *testing.T) {
+			fragment, err := os.ReadFile(input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			description, err := parseHTMLFragment(string(fragment))
+			if err != nil {
+				t.Fatalf("parseHTMLFragment: %v", err)
+			}
+
+			for ext, got := range map[string]string{
+				"txt":  description.Text,
+				"md":   description.Markdown,
+				"html": description.HTML,
+			} {
+				golden := filepath.Join("testdata", "descriptions", name+".golden."+ext)
+				if *updateGolden {
+					if err := os.WriteFile(golden, []byte(got+"\n"), 0o644); err != nil {
+						t.Fatal(err)
+					}
+					continue
+				}
+
+				want, err := os.ReadFile(golden)
+				if err != nil {
+					t.Fatalf("reading golden file, run go test -update to create it: %v", err)
+				}
+				if got != strings.TrimSuffix(string(want), "\n") {
+					t.Errorf("%s does not match %s\n got:\n%s\nwant:\n%s", ext, golden, got, want)
+				}
+			}
+		})
+	}
+}
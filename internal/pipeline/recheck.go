@@ -0,0 +1,184 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/jobs-scraper/internal/models"
+	"github.com/jobs-scraper/internal/utils"
+)
+
+// recheckResult is the outcome of fetching one stored posting again
+type recheckResult struct {
+	job         models.Job
+	description *models.JobDescription // The posting as fetched again, nil when it was removed or failed
+	closed      bool
+	err         error
+}
+
+// RecheckJobs fetches up to limit stored open jobs again, least recently checked first, and marks
+// the postings that no longer accept applications or were removed as closed. Only the sources
+// implementing RecheckableSource are rechecked.
+func (p *JobPipeline) RecheckJobs(ctx context.Context, repos Repositories, limit int) error {
+	sources := make(map[string]RecheckableSource)
+	names := make([]string, 0, len(p.sources))
+	for _, source := range p.sources {
+		if recheckable, ok := source.(RecheckableSource); ok {
+			sources[source.Name()] = recheckable
+			names = append(names, source.Name())
+		}
+	}
+	if len(sources) == 0 {
+		return fmt.Errorf("no registered job source can recheck jobs")
+	}
+
+	jobs, err := repos.Jobs.GetOpenJobs(names, limit)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Rechecking %d open jobs\n", len(jobs))
+
+	ctx, cancel := p.stopOnBreakerAbort(ctx)
+	defer cancel(nil)
+
+	closed, open, failed, failedBatches := 0, 0, 0, 0
+	batch := make([]recheckResult, 0, p.batchSize)
+	flush := func() {
+		if err := saveRecheckResults(repos, batch); err != nil {
+			failedBatches++
+			fmt.Printf("Failed to save %d rechecked jobs: %v\n", len(batch), err)
+		}
+		batch = batch[:0]
+	}
+
+	for result := range recheckPostings(ctx, sources, jobs, p.numWorkers) {
+		switch {
+		case result.err != nil:
+			failed++
+			log.Printf("Error rechecking %s job %d: %v", result.job.Source, result.job.ID, result.err)
+			continue
+		case result.closed:
+			closed++
+		default:
+			open++
+		}
+
+		batch = append(batch, result)
+		if len(batch) >= p.batchSize {
+			flush()
+		}
+	}
+	flush()
+
+	fmt.Printf("Rechecked %d jobs: %d closed, %d still open, %d failed\n", closed+open, closed, open, failed)
+	p.printBreakerSummary()
+
+	if failedBatches > 0 {
+		return fmt.Errorf("failed to save %d batches of rechecked jobs", failedBatches)
+	}
+	return context.Cause(ctx)
+}
+
+// recheckPostings fetches the postings of jobs again with numWorkers workers
+func recheckPostings(ctx context.Context, sources map[string]RecheckableSource, jobs []models.Job, numWorkers int) <-chan recheckResult {
+	jobChan := make(chan models.Job)
+	go func() {
+		defer close(jobChan)
+		for _, job := range jobs {
+			select {
+			case <-ctx.Done():
+				return
+			case jobChan <- job:
+			}
+		}
+	}()
+
+	resultChan := make(chan recheckResult, 100)
+
+	var wg sync.WaitGroup
+	for range numWorkers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobChan {
+				resultChan <- recheckPosting(ctx, sources[job.Source], job)
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(resultChan)
+	}()
+
+	return resultChan
+}
+
+func recheckPosting(ctx context.Context, source RecheckableSource, job models.Job) recheckResult {
+	jd, err := source.RecheckJob(ctx, job)
+	if err != nil {
+		if postingRemoved(err) {
+			markClosed(&job)
+			return recheckResult{job: job, closed: true}
+		}
+		return recheckResult{job: job, err: err}
+	}
+
+	structureCriteria(&jd)
+	DetectLanguages(&jd)
+	if jd.Closed {
+		markClosed(&job)
+	}
+	return recheckResult{job: job, description: &jd, closed: jd.Closed}
+}
+
+// postingRemoved reports whether err means the posting page no longer exists
+func postingRemoved(err error) bool {
+	var statusErr *utils.StatusError
+	if !errors.As(err, &statusErr) {
+		return false
+	}
+	return statusErr.StatusCode == http.StatusNotFound || statusErr.StatusCode == http.StatusGone
+}
+
+// markClosed records that job was found closed just now, unless it already was
+func markClosed(job *models.Job) {
+	if job.ClosedAt == nil {
+		closedAt := time.Now().UTC()
+		job.ClosedAt = &closedAt
+	}
+}
+
+// saveRecheckResults stores the descriptions fetched again and marks the jobs as checked or closed
+func saveRecheckResults(repos Repositories, results []recheckResult) error {
+	if len(results) == 0 {
+		return nil
+	}
+
+	var descriptions []models.JobDescription
+	var closedJobs, openJobs []models.Job
+	for _, result := range results {
+		// A closed page without a description keeps the stored one
+		if result.description != nil && result.description.Description != "" {
+			descriptions = append(descriptions, *result.description)
+		}
+		if result.closed {
+			closedJobs = append(closedJobs, result.job)
+		} else {
+			openJobs = append(openJobs, result.job)
+		}
+	}
+
+	if err := repos.JobDescriptions.SaveJobDescriptions(descriptions); err != nil {
+		return err
+	}
+	if err := repos.Jobs.MarkClosed(closedJobs); err != nil {
+		return err
+	}
+	return repos.Jobs.MarkChecked(openJobs)
+}
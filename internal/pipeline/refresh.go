@@ -0,0 +1,140 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jobs-scraper/internal/models"
+)
+
+const (
+	storedCheckBatchSize     = 50
+	storedCheckFlushInterval = 500 * time.Millisecond
+)
+
+// RefreshPolicy decides which stored descriptions are fetched again
+type RefreshPolicy struct {
+	MaxDescriptionAge time.Duration // Descriptions older than this are fetched again, 0 keeps them forever
+	RefetchAll        bool          // Fetch every description regardless of what is stored
+}
+
+// since returns the oldest save time of a description that is still fresh
+func (p RefreshPolicy) since(now time.Time) time.Time {
+	if p.MaxDescriptionAge <= 0 {
+		return time.Time{}
+	}
+	return now.Add(-p.MaxDescriptionAge)
+}
+
+// StoredJobIndex tells which listed jobs already have a stored description
+type StoredJobIndex interface {
+	// FreshJobs returns the jobs among the given ones whose description was saved at or after since
+	FreshJobs(jobs []models.Job, since time.Time) ([]models.Job, error)
+
+	// TouchJobs records that stored jobs were listed again
+	TouchJobs(jobs []models.Job) error
+}
+
+// storedJobs implements StoredJobIndex on top of the job repositories
+type storedJobs struct {
+	repos Repositories
+}
+
+func (s storedJobs) FreshJobs(jobs []models.Job, since time.Time) ([]models.Job, error) {
+	return s.repos.JobDescriptions.FreshJobs(jobs, since)
+}
+
+func (s storedJobs) TouchJobs(jobs []models.Job) error {
+	return s.repos.Jobs.TouchJobs(jobs)
+}
+
+// SkipStoredJobs drops jobs whose description is stored and still fresh, checking the index in
+// bulk. Skipped jobs are touched in the index and passed to onSkip.
+func SkipStoredJobs(ctx context.Context, jobChan <-chan models.Job, index StoredJobIndex, policy RefreshPolicy, onSkip func([]models.Job)) <-chan models.Job {
+	outChan := make(chan models.Job, 100)
+
+	go func() {
+		defer close(outChan)
+
+		batch := make([]models.Job, 0, storedCheckBatchSize)
+		ticker := time.NewTicker(storedCheckFlushInterval)
+		defer ticker.Stop()
+
+		flush := func() bool {
+			if len(batch) == 0 {
+				return true
+			}
+
+			toFetch := filterStoredJobs(batch, index, policy, onSkip)
+			batch = make([]models.Job, 0, storedCheckBatchSize)
+
+			for _, job := range toFetch {
+				select {
+				case <-ctx.Done():
+					return false
+				case outChan <- job:
+				}
+			}
+			return true
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case job, ok := <-jobChan:
+				if !ok {
+					flush()
+					return
+				}
+				batch = append(batch, job)
+				if len(batch) >= storedCheckBatchSize && !flush() {
+					return
+				}
+			case <-ticker.C:
+				if !flush() {
+					return
+				}
+			}
+		}
+	}()
+
+	return outChan
+}
+
+// filterStoredJobs returns the jobs of batch whose descriptions have to be fetched
+func filterStoredJobs(batch []models.Job, index StoredJobIndex, policy RefreshPolicy, onSkip func([]models.Job)) []models.Job {
+	fresh, err := index.FreshJobs(batch, policy.since(time.Now()))
+	if err != nil {
+		// Fetching again is safer than losing jobs
+		log.Printf("Error checking stored job descriptions, fetching all: %v", err)
+		return batch
+	}
+	if len(fresh) == 0 {
+		return batch
+	}
+
+	if err := index.TouchJobs(fresh); err != nil {
+		log.Printf("Error updating last seen time of stored jobs: %v", err)
+	}
+	if onSkip != nil {
+		onSkip(fresh)
+	}
+
+	skipped := make(map[jobKey]struct{}, len(fresh))
+	for _, job := range fresh {
+		skipped[jobKey{source: job.Source, id: job.ID}] = struct{}{}
+	}
+
+	toFetch := make([]models.Job, 0, len(batch)-len(fresh))
+	for _, job := range batch {
+		if _, ok := skipped[jobKey{source: job.Source, id: job.ID}]; !ok {
+			toFetch = append(toFetch, job)
+		}
+	}
+
+	fmt.Printf("Skipping %d jobs with stored descriptions\n", len(fresh))
+	return toFetch
+}
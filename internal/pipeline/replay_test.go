@@ -0,0 +1,166 @@
+package pipeline
+
+import (
+	"context"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jobs-scraper/internal/models"
+	"github.com/jobs-scraper/internal/utils"
+)
+
+// memoryBatchStore keeps every saved batch in memory instead of writing it to the database
+type memoryBatchStore struct {
+	saved []models.JobWithDescription
+}
+
+func (s *memoryBatchStore) SaveBatch(batch []models.JobWithDescription) ([]models.Job, error) {
+	s.saved = append(s.saved, batch...)
+	jobs := make([]models.Job, 0, len(batch))
+	for _, item := range batch {
+		jobs = append(jobs, item.Job)
+	}
+	return jobs, nil
+}
+
+// newReplayScraper returns the scraper of the scraper command serving every request from the
+// sample cassette, a request that was not recorded fails
+func newReplayScraper(t *testing.T) *Scraper {
+	t.Helper()
+
+	cassette, err := utils.NewCassette("../../cassettes/linkedin-sample", utils.CassetteReplay)
+	if err != nil {
+		t.Fatalf("NewCassette: %v", err)
+	}
+	scraper := NewScraper(Config{Distance: "25", SortBy: "R", MaxRetries: 1, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond})
+	scraper.UseCassette(cassette)
+	return scraper
+}
+
+// sampleQuery is the search recorded in the sample cassette, the scraper command's default
+var sampleQuery = models.SearchQuery{Keywords: "Frontend Developer", Location: "Japan", FWT: "2,3"}
+
+func TestReplaySampleCassetteListsDefaultPages(t *testing.T) {
+	jobChan := make(chan models.Job, 10)
+	if err := newReplayScraper(t).ListJobs(context.Background(), 10, sampleQuery, jobChan); err != nil {
+		t.Fatalf("listing the default 10 pages from the cassette: %v", err)
+	}
+	if len(jobChan) != 2 {
+		t.Errorf("listed %d jobs, want 2", len(jobChan))
+	}
+}
+
+func TestReplaySampleCassette(t *testing.T) {
+	ctx := context.Background()
+	sources := []JobSource{newReplayScraper(t)}
+
+	// The stages of JobPipeline.process, without the database
+	matches := NewQueryMatches()
+	matchChan, listErrChan := GetJobs(ctx, sources, 10, []models.SearchQuery{sampleQuery}, noCheckpoints{})
+	jobChan := DedupeJobs(ctx, matchChan, matches)
+	items := GetJobDescription(ctx, sources, jobChan, 5)
+	items = NormalizeSalaries(items, ExchangeRates{})
+	items = NormalizeLocations(items, DefaultGazetteer())
+	items = DetectLanguageRequirements(items)
+
+	store := &memoryBatchStore{}
+	for report := range SinkJobs(items, store, 100, time.Minute) {
+		if report.Err != nil {
+			t.Errorf("batch %d: %v", report.Number, report.Err)
+		}
+	}
+	if err := listingError(listErrChan); err != nil {
+		t.Errorf("listing: %v", err)
+	}
+
+	if len(store.saved) != 2 {
+		t.Fatalf("saved %d jobs, want 2", len(store.saved))
+	}
+	sort.Slice(store.saved, func(i, j int) bool { return store.saved[i].Job.ID < store.saved[j].Job.ID })
+	first, second := store.saved[0], store.saved[1]
+
+	type summary struct {
+		ID                           int64
+		Title, Company               string
+		CountryCode, Region, City    string
+		SalaryMin, SalaryMax         float64
+		SalaryCurrency, SalaryPeriod string
+		Seniority, EmploymentType    string
+		Language                     string
+		LanguageRequirements         []models.LanguageRequirement
+		HasListItem                  bool
+	}
+	summarize := func(item models.JobWithDescription, listItem string) summary {
+		return summary{
+			ID:                   item.Job.ID,
+			Title:                item.Job.Title,
+			Company:              item.Job.Company,
+			CountryCode:          item.Job.CountryCode,
+			Region:               item.Job.Region,
+			City:                 item.Job.City,
+			SalaryMin:            item.Job.SalaryMin,
+			SalaryMax:            item.Job.SalaryMax,
+			SalaryCurrency:       item.Job.SalaryCurrency,
+			SalaryPeriod:         item.Job.SalaryPeriod,
+			Seniority:            item.JobDescription.Seniority,
+			EmploymentType:       item.JobDescription.EmploymentType,
+			Language:             item.JobDescription.Language,
+			LanguageRequirements: item.JobDescription.LanguageRequirements,
+			HasListItem:          containsLine(item.JobDescription.Description, listItem),
+		}
+	}
+
+	tests := []struct {
+		got, want summary
+	}{
+		{
+			got: summarize(first, "• Build new features in React and TypeScript"),
+			want: summary{
+				ID: 4300000001, Title: "Frontend Developer", Company: "Example Corp",
+				CountryCode: "JP", Region: "Tokyo", City: "Tokyo",
+				// From the salary shown on the search card
+				SalaryMin: 6000000, SalaryMax: 9000000, SalaryCurrency: "JPY", SalaryPeriod: models.SalaryPeriodYear,
+				Seniority: "mid_senior", EmploymentType: "full_time",
+				Language: "en",
+				LanguageRequirements: []models.LanguageRequirement{
+					{Language: "en", Level: "business", Required: true, Evidence: "Business level English"},
+				},
+				HasListItem: true,
+			},
+		},
+		{
+			got: summarize(second, "• Own the checkout frontend"),
+			want: summary{
+				ID: 4300000002, Title: "Senior Frontend Engineer (React)", Company: "Sample KK",
+				CountryCode: "JP", Region: "Osaka", City: "Osaka",
+				// From the description, the card has no salary
+				SalaryMin: 8000000, SalaryMax: 11000000, SalaryCurrency: "JPY", SalaryPeriod: models.SalaryPeriodYear,
+				// From the Japanese criteria labels
+				Seniority: "mid_senior", EmploymentType: "full_time",
+				Language: "en",
+				LanguageRequirements: []models.LanguageRequirement{
+					{Language: "ja", Level: "business", Required: true, Evidence: "JLPT N2"},
+				},
+				HasListItem: true,
+			},
+		},
+	}
+	for _, tt := range tests {
+		if !reflect.DeepEqual(tt.got, tt.want) {
+			t.Errorf("job %d\n got %+v\nwant %+v", tt.want.ID, tt.got, tt.want)
+		}
+	}
+
+	matched := matches.ForJobs([]models.Job{first.Job, second.Job})
+	if jobs := matched[sampleQuery.Key()]; len(matched) != 1 || len(jobs) != 2 {
+		t.Errorf("matches %v, want both jobs under query %q", matched, sampleQuery.Key())
+	}
+}
+
+func containsLine(text, line string) bool {
+	return slices.Contains(strings.Split(text, "\n"), line)
+}
@@ -0,0 +1,477 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jobs-scraper/internal/models"
+)
+
+// Salary is a pay range parsed from text
+type Salary struct {
+	Min      float64
+	Max      float64
+	Currency string // ISO 4217 code
+	Period   string // One of the models.SalaryPeriod* constants
+}
+
+// currencyCodes are the ISO codes recognized next to an amount
+const currencyCodes = `USD|EUR|GBP|JPY|CNY|KRW|INR|AUD|CAD|SGD|HKD|NZD|CHF|SEK|NOK|DKK|PLN`
+
+// salaryAmountPattern matches an amount with an optional currency before it, a multiplier such
+// as k, M or 万 and a currency after it
+var salaryAmountPattern = regexp.MustCompile(
+	`(US\$|AU?\$|CA?\$|SG?\$|HK\$|NZ\$|CN¥|RMB|[$€£¥￥₩₹]|\b(?:` + currencyCodes + `)\s?)?` +
+		`(\d(?:[\d.,']*\d)?)` +
+		`\s?(million|Million|mn|[kKmM万千])?` +
+		`\s?(円|元|€|\b(?:` + currencyCodes + `)\b)?`)
+
+// salaryRangeSeparator joins the two amounts of a range
+var salaryRangeSeparator = regexp.MustCompile(`^\s*(?:-|–|—|~|〜|～|to|bis)\s*$`)
+
+// currencySymbols maps the symbols and prefixes of salaryAmountPattern to ISO codes
+var currencySymbols = map[string]string{
+	"$": "USD", "US$": "USD", "A$": "AUD", "AU$": "AUD", "C$": "CAD", "CA$": "CAD",
+	"S$": "SGD", "SG$": "SGD", "HK$": "HKD", "NZ$": "NZD", "CN¥": "CNY", "RMB": "CNY", "元": "CNY",
+	"€": "EUR", "£": "GBP", "¥": "JPY", "￥": "JPY", "円": "JPY", "₩": "KRW", "₹": "INR",
+}
+
+var salaryMultipliers = map[string]float64{
+	"k": 1e3, "K": 1e3, "千": 1e3, "万": 1e4,
+	"m": 1e6, "M": 1e6, "mn": 1e6, "million": 1e6, "Million": 1e6,
+}
+
+// salaryPeriodWords are the words near an amount that tell its period, matched lowercased
+var salaryPeriodWords = map[string][]string{
+	models.SalaryPeriodHour:  {"per hour", "an hour", "/hour", "/hr", "hourly", "pro stunde", "時給"},
+	models.SalaryPeriodDay:   {"per day", "a day", "/day", "daily", "pro tag", "日給"},
+	models.SalaryPeriodWeek:  {"per week", "a week", "/week", "/wk", "weekly", "pro woche", "週給"},
+	models.SalaryPeriodMonth: {"per month", "a month", "/month", "/mo", "monthly", "pro monat", "monatlich", "月給", "月収", "月額"},
+	models.SalaryPeriodYear:  {"per year", "a year", "/year", "/yr", "per annum", "p.a.", "annual", "annually", "yearly", "pro jahr", "jährlich", "年収", "年俸"},
+}
+
+// periodsPerYear converts an amount of each period to a yearly amount, assuming full time work
+var periodsPerYear = map[string]float64{
+	models.SalaryPeriodHour:  2080,
+	models.SalaryPeriodDay:   260,
+	models.SalaryPeriodWeek:  52,
+	models.SalaryPeriodMonth: 12,
+	models.SalaryPeriodYear:  1,
+}
+
+// salaryAmount is one amount found by salaryAmountPattern
+type salaryAmount struct {
+	value      float64
+	currency   string
+	multiplied bool
+	start, end int
+}
+
+// parseSalary finds the first pay range with a currency in text, e.g. "¥6M–9M per year",
+// "$120k-$150k" or "€70.000 brutto". Without a period word the period is guessed from the amount.
+func parseSalary(text string) (Salary, bool) {
+	ranges := salaryRanges(text)
+	if len(ranges) == 0 {
+		return Salary{}, false
+	}
+	return ranges[0].salary, true
+}
+
+// salaryRange is a pay range found in text, with its position
+type salaryRange struct {
+	salary Salary
+	span   textMatch
+}
+
+// salaryRanges returns every pay range with a currency in text, in order
+func salaryRanges(text string) []salaryRange {
+	var ranges []salaryRange
+	amounts := findSalaryAmounts(text)
+
+	for i := 0; i < len(amounts); i++ {
+		low, high := amounts[i], amounts[i]
+		if i+1 < len(amounts) && salaryRangeSeparator.MatchString(text[amounts[i].end:amounts[i+1].start]) {
+			high = amounts[i+1]
+			i++
+			// "$120-150k" shares the multiplier of the upper bound
+			if !low.multiplied && high.multiplied && low.value < high.value {
+				low.value *= high.value / amountWithoutMultiplier(text, high)
+			}
+		}
+
+		currency := low.currency
+		if currency == "" {
+			currency = high.currency
+		}
+		if currency == "" {
+			// Numbers without a currency are years of experience, counts and such
+			continue
+		}
+
+		salary := Salary{
+			Min:      math.Min(low.value, high.value),
+			Max:      math.Max(low.value, high.value),
+			Currency: currency,
+			Period:   salaryPeriodNear(text, low.start, high.end),
+		}
+		if salary.Period == "" {
+			salary.Period = guessSalaryPeriod(salary.Max, currency)
+		}
+		ranges = append(ranges, salaryRange{salary: salary, span: textMatch{start: low.start, end: high.end}})
+	}
+
+	return ranges
+}
+
+func findSalaryAmounts(text string) []salaryAmount {
+	var amounts []salaryAmount
+	for _, match := range salaryAmountPattern.FindAllStringSubmatchIndex(text, -1) {
+		amount := salaryAmount{start: match[0], end: match[1]}
+
+		value, ok := parseSalaryNumber(text[match[4]:match[5]])
+		if !ok {
+			continue
+		}
+		// "$5B in funding" and "10x" are not amounts
+		if match[6] < 0 && match[8] < 0 && isLetterAt(text, match[5]) {
+			continue
+		}
+
+		if match[6] >= 0 {
+			multiplier := text[match[6]:match[7]]
+			// "5 months" is not five million
+			if next := match[7]; !isLetterAt(text, next) || multiplier == "万" || multiplier == "千" {
+				value *= salaryMultipliers[multiplier]
+				amount.multiplied = true
+			} else {
+				amount.end = match[5]
+			}
+		}
+		amount.value = value
+
+		for _, group := range []int{2, 8} {
+			if match[group] < 0 {
+				continue
+			}
+			symbol := strings.TrimSpace(text[match[group]:match[group+1]])
+			if code, ok := currencySymbols[symbol]; ok {
+				amount.currency = code
+			} else {
+				amount.currency = symbol
+			}
+		}
+
+		amounts = append(amounts, amount)
+	}
+	return amounts
+}
+
+// amountWithoutMultiplier reads the number of amount again, before its multiplier was applied
+func amountWithoutMultiplier(text string, amount salaryAmount) float64 {
+	match := salaryAmountPattern.FindStringSubmatch(text[amount.start:amount.end])
+	value, _ := parseSalaryNumber(match[2])
+	return value
+}
+
+// parseSalaryNumber reads 120,000, 70.000 and 120'000 as thousands and 1.5 and 1,5 as decimals.
+// With both separators the last one is the decimal separator.
+func parseSalaryNumber(number string) (float64, bool) {
+	number = strings.ReplaceAll(number, "'", "")
+	lastDot, lastComma := strings.LastIndex(number, "."), strings.LastIndex(number, ",")
+
+	switch {
+	case lastDot >= 0 && lastComma >= 0:
+		decimal := "."
+		if lastComma > lastDot {
+			decimal = ","
+		}
+		thousands := map[string]string{".": ",", ",": "."}[decimal]
+		number = strings.ReplaceAll(number, thousands, "")
+		number = strings.Replace(number, decimal, ".", 1)
+	case lastDot >= 0 || lastComma >= 0:
+		separator := "."
+		if lastComma >= 0 {
+			separator = ","
+		}
+		if thousandsGroups(number, separator) {
+			number = strings.ReplaceAll(number, separator, "")
+		} else {
+			number = strings.Replace(number, separator, ".", 1)
+		}
+	}
+
+	value, err := strconv.ParseFloat(number, 64)
+	return value, err == nil && value > 0
+}
+
+// thousandsGroups reports whether every group after the first separator has three digits
+func thousandsGroups(number, separator string) bool {
+	groups := strings.Split(number, separator)
+	for _, group := range groups[1:] {
+		if len(group) != 3 {
+			return false
+		}
+	}
+	return len(groups[0]) <= 3
+}
+
+func isLetterAt(text string, i int) bool {
+	if i >= len(text) {
+		return false
+	}
+	c := text[i]
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// salaryPeriodNear returns the period of the period word closest to the amount in text[start:end],
+// looking only at the line of the amount
+func salaryPeriodNear(text string, start, end int) string {
+	lineStart := strings.LastIndex(text[:start], "\n") + 1
+	lineEnd := len(text)
+	if i := strings.Index(text[end:], "\n"); i >= 0 {
+		lineEnd = end + i
+	}
+	line := strings.ToLower(text[lineStart:lineEnd])
+	start, end = start-lineStart, end-lineStart
+
+	period, best := "", -1
+	for wordPeriod, words := range salaryPeriodWords {
+		for _, word := range words {
+			distance, ok := wordDistance(line, word, start, end)
+			if ok && (best < 0 || distance < best || (distance == best && wordPeriod < period)) {
+				period, best = wordPeriod, distance
+			}
+		}
+	}
+	return period
+}
+
+// wordDistance returns how many bytes the occurrence of word closest to line[start:end] is away from it
+func wordDistance(line, word string, start, end int) (int, bool) {
+	best := -1
+	offset := 0
+	for {
+		i := strings.Index(line[offset:], word)
+		if i < 0 {
+			break
+		}
+		i += offset
+		offset = i + len(word)
+
+		distance := 0
+		switch {
+		case i >= end:
+			distance = i - end
+		case i+len(word) <= start:
+			distance = start - (i + len(word))
+		}
+		if best < 0 || distance < best {
+			best = distance
+		}
+	}
+	return best, best >= 0
+}
+
+// salaryUnitScale is how many units of a currency are roughly worth one US dollar, for the
+// currencies where this changes which period an amount is likely to be
+var salaryUnitScale = map[string]float64{"JPY": 150, "KRW": 1400, "INR": 85, "CNY": 7}
+
+// guessSalaryPeriod picks the period an amount without a period word most likely has
+func guessSalaryPeriod(amount float64, currency string) string {
+	if scale, ok := salaryUnitScale[currency]; ok {
+		amount /= scale
+	}
+
+	switch {
+	case amount >= 15000:
+		return models.SalaryPeriodYear
+	case amount >= 1000:
+		return models.SalaryPeriodMonth
+	case amount >= 200:
+		return models.SalaryPeriodDay
+	default:
+		return models.SalaryPeriodHour
+	}
+}
+
+// ExchangeRates converts salaries into the Base currency. Rates holds how many units of Base one
+// unit of each currency is worth.
+type ExchangeRates struct {
+	Base  string             `json:"base"`
+	Rates map[string]float64 `json:"rates"`
+}
+
+// LoadExchangeRates reads an exchange rate table from a JSON file
+func LoadExchangeRates(path string) (ExchangeRates, error) {
+	var rates ExchangeRates
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return rates, fmt.Errorf("error reading exchange rates %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, &rates); err != nil {
+		return rates, fmt.Errorf("error decoding exchange rates %s: %w", path, err)
+	}
+
+	rates.Base = strings.ToUpper(rates.Base)
+	if rates.Base == "" {
+		return rates, fmt.Errorf("exchange rates %s have no base currency", path)
+	}
+	for currency, rate := range rates.Rates {
+		if rate <= 0 {
+			return rates, fmt.Errorf("exchange rates %s have an invalid rate %v for %s", path, rate, currency)
+		}
+	}
+	return rates, nil
+}
+
+// Convert returns amount of currency in the base currency
+func (r ExchangeRates) Convert(amount float64, currency string) (float64, bool) {
+	currency = strings.ToUpper(currency)
+	if r.Base == "" {
+		return 0, false
+	}
+	if currency == r.Base {
+		return amount, true
+	}
+	rate, ok := r.Rates[currency]
+	return amount * rate, ok
+}
+
+// salaryCriteriaWords mark the criteria whose values may hold a salary, matched lowercased
+var salaryCriteriaWords = []string{"salary", "compensation", "pay", "gehalt", "給与", "年収", "報酬"}
+
+// descriptionSalaryWords mark the description lines that may hold a salary, along with the
+// salaryCriteriaWords and salaryPeriodWords
+var descriptionSalaryWords = []string{"brutto", "gross", "base", "range", "月給", "賃金"}
+
+// normalizeSalary fills the salary of job from its card, criteria or description when the source
+// gave none, then sets the yearly range in the base currency of rates, or in the salary currency
+// when rates has no rate for it
+func normalizeSalary(job *models.Job, jd models.JobDescription, rates ExchangeRates) {
+	if job.SalaryMin == 0 && job.SalaryMax == 0 {
+		if salary, ok := findSalary(*job, jd); ok {
+			job.SalaryMin, job.SalaryMax = salary.Min, salary.Max
+			job.SalaryCurrency, job.SalaryPeriod = salary.Currency, salary.Period
+		}
+	}
+
+	job.SalaryAnnualMin, job.SalaryAnnualMax, job.SalaryAnnualCurrency = 0, 0, ""
+	perYear, ok := periodsPerYear[job.SalaryPeriod]
+	if !ok || job.SalaryCurrency == "" || (job.SalaryMin == 0 && job.SalaryMax == 0) {
+		return
+	}
+
+	annualMin, annualMax := job.SalaryMin*perYear, job.SalaryMax*perYear
+	if convertedMin, ok := rates.Convert(annualMin, job.SalaryCurrency); ok {
+		convertedMax, _ := rates.Convert(annualMax, job.SalaryCurrency)
+		job.SalaryAnnualMin, job.SalaryAnnualMax = math.Round(convertedMin), math.Round(convertedMax)
+		job.SalaryAnnualCurrency = rates.Base
+		return
+	}
+	job.SalaryAnnualMin, job.SalaryAnnualMax = math.Round(annualMin), math.Round(annualMax)
+	job.SalaryAnnualCurrency = strings.ToUpper(job.SalaryCurrency)
+}
+
+// findSalary looks for a salary in the card insight, then the salary criteria, then the description
+func findSalary(job models.Job, jd models.JobDescription) (Salary, bool) {
+	if salary, ok := parseSalary(job.SalaryInsight); ok {
+		return salary, true
+	}
+
+	for label, value := range jd.Criteria {
+		label = strings.ToLower(label)
+		for _, word := range salaryCriteriaWords {
+			if !strings.Contains(label, word) {
+				continue
+			}
+			if salary, ok := parseSalary(value); ok {
+				return salary, true
+			}
+		}
+	}
+
+	for _, line := range strings.Split(jd.Description, "\n") {
+		if salary, ok := parseDescriptionSalary(line); ok {
+			return salary, true
+		}
+	}
+	return Salary{}, false
+}
+
+// salarySentence separates the sentences of a description line
+var salarySentence = regexp.MustCompile(`[.!?]\s+|[。！？]`)
+
+// parseDescriptionSalary returns the pay range of line closest to a word about pay in the same
+// sentence, so that in "We raised $50M. Salary $100,000" the funding round is not the salary
+func parseDescriptionSalary(line string) (Salary, bool) {
+	best, bestDistance := Salary{}, -1
+	for _, sentence := range salarySentence.Split(line, -1) {
+		keywords := salaryKeywords(sentence)
+		if len(keywords) == 0 {
+			continue
+		}
+		for _, candidate := range salaryRanges(sentence) {
+			for _, keyword := range keywords {
+				if distance := matchDistance(candidate.span, keyword); bestDistance < 0 || distance < bestDistance {
+					best, bestDistance = candidate.salary, distance
+				}
+			}
+		}
+	}
+	return best, bestDistance >= 0
+}
+
+// salaryKeywords finds the words about pay in sentence, whole words only so that "database" does
+// not count as "base"
+func salaryKeywords(sentence string) []textMatch {
+	lower := strings.ToLower(sentence)
+	if len(lower) != len(sentence) {
+		lower = sentence
+	}
+
+	var words []string
+	words = append(words, salaryCriteriaWords...)
+	words = append(words, descriptionSalaryWords...)
+	for _, periodWords := range salaryPeriodWords {
+		words = append(words, periodWords...)
+	}
+
+	var keywords []textMatch
+	for _, word := range words {
+		for offset := 0; ; {
+			i := strings.Index(lower[offset:], word)
+			if i < 0 {
+				break
+			}
+			start, end := offset+i, offset+i+len(word)
+			offset = end
+			if (start > 0 && isLetterAt(lower, start-1) && isLetterAt(lower, start)) || (isLetterAt(lower, end) && isLetterAt(lower, end-1)) {
+				continue
+			}
+			keywords = append(keywords, textMatch{value: word, start: start, end: end})
+		}
+	}
+	return keywords
+}
+
+// NormalizeSalaries fills in and normalizes the salary of every job on its way to the database. Like
+// SinkJobs it does not watch the context, a cancelled run still writes every fetched job.
+func NormalizeSalaries(jobChan <-chan models.JobWithDescription, rates ExchangeRates) <-chan models.JobWithDescription {
+	outChan := make(chan models.JobWithDescription, 100)
+
+	go func() {
+		defer close(outChan)
+		for item := range jobChan {
+			normalizeSalary(&item.Job, item.JobDescription, rates)
+			outChan <- item
+		}
+	}()
+
+	return outChan
+}
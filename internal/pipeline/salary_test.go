@@ -0,0 +1,61 @@
+package pipeline
+
+import (
+	"testing"
+
+	"github.com/jobs-scraper/internal/models"
+)
+
+func TestParseSalary(t *testing.T) {
+	tests := []struct {
+		text   string
+		want   Salary
+		wantOK bool
+	}{
+		{text: "¥6M–9M", want: Salary{Min: 6e6, Max: 9e6, Currency: "JPY", Period: models.SalaryPeriodYear}, wantOK: true},
+		{text: "€70.000", want: Salary{Min: 70000, Max: 70000, Currency: "EUR", Period: models.SalaryPeriodYear}, wantOK: true},
+		{text: "600万円〜900万円", want: Salary{Min: 6e6, Max: 9e6, Currency: "JPY", Period: models.SalaryPeriodYear}, wantOK: true},
+		{text: "$120-150k", want: Salary{Min: 120000, Max: 150000, Currency: "USD", Period: models.SalaryPeriodYear}, wantOK: true},
+		{text: "$120k-$150k", want: Salary{Min: 120000, Max: 150000, Currency: "USD", Period: models.SalaryPeriodYear}, wantOK: true},
+		{text: "$50/hour", want: Salary{Min: 50, Max: 50, Currency: "USD", Period: models.SalaryPeriodHour}, wantOK: true},
+		{text: "£45,000 - £55,000 per annum", want: Salary{Min: 45000, Max: 55000, Currency: "GBP", Period: models.SalaryPeriodYear}, wantOK: true},
+		// Numbers without a currency are not salaries
+		{text: "3-5 years of experience"},
+		// Currencies written as words are not recognized
+		{text: "5 million yen"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			got, ok := parseSalary(tt.text)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("parseSalary(%q) = %+v, %v, want %+v, %v", tt.text, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestParseDescriptionSalary(t *testing.T) {
+	tests := []struct {
+		line   string
+		want   Salary
+		wantOK bool
+	}{
+		{line: "年収600万円〜900万円", want: Salary{Min: 6e6, Max: 9e6, Currency: "JPY", Period: models.SalaryPeriodYear}, wantOK: true},
+		{line: "Salary: ¥6M–9M depending on experience", want: Salary{Min: 6e6, Max: 9e6, Currency: "JPY", Period: models.SalaryPeriodYear}, wantOK: true},
+		{line: "We pay $50/hour for contractors", want: Salary{Min: 50, Max: 50, Currency: "USD", Period: models.SalaryPeriodHour}, wantOK: true},
+		{line: "We raised $50M. Salary $100,000", want: Salary{Min: 100000, Max: 100000, Currency: "USD", Period: models.SalaryPeriodYear}, wantOK: true},
+		// An amount needs a word about pay in the same sentence
+		{line: "Our customers processed €70.000 last week."},
+		{line: "Salary: 5 million yen"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			got, ok := parseDescriptionSalary(tt.line)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("parseDescriptionSalary(%q) = %+v, %v, want %+v, %v", tt.line, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
@@ -3,9 +3,11 @@ package pipeline
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"net/url"
 	"path"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -15,7 +17,10 @@ import (
 	"github.com/jobs-scraper/internal/utils"
 )
 
+const linkedInBaseURL = "https://www.linkedin.com"
+
 type Config struct {
+	BaseURL        string        // LinkedIn base URL, overridable to point the scraper at a fake backend
 	Timespan       string        // Time filter for job postings (e.g., "r86400" for last 24 hours)
 	Distance       string        // Search radius in miles (e.g., "25")
 	SortBy         string        // Sort results by (R for relevance, DD for date posted)
@@ -23,10 +28,39 @@ type Config struct {
 	BaseDelay      time.Duration // Base delay for exponential backoff
 	MaxDelay       time.Duration // Maximum delay between retries
 	RequestTimeout time.Duration // Timeout for individual HTTP requests
+
+	RequestInterval       time.Duration // Minimum time between two requests to LinkedIn, shared by all workers
+	MaxConcurrentRequests int           // Requests in flight to LinkedIn at the same time
+
+	Breaker utils.BreakerConfig // When to pause all requests to LinkedIn and when to give up
+
+	Selectors *SelectorProfile // CSS selectors of the LinkedIn pages, DefaultSelectorProfile when nil
 }
 
 type Scraper struct {
-	config Config
+	*sourceClient
+
+	config  Config
+	refresh RefreshPolicy
+}
+
+var (
+	timespanPattern = regexp.MustCompile(`^r\d+$`)
+	distancePattern = regexp.MustCompile(`^\d+$`)
+)
+
+// Validate checks the search filters of the config
+func (c Config) Validate() error {
+	if c.Timespan != "" && !timespanPattern.MatchString(c.Timespan) {
+		return fmt.Errorf("invalid timespan %q, expected r followed by seconds, e.g. r86400", c.Timespan)
+	}
+	if c.Distance != "" && !distancePattern.MatchString(c.Distance) {
+		return fmt.Errorf("invalid distance %q, expected a number of miles", c.Distance)
+	}
+	if c.SortBy != "" && c.SortBy != "R" && c.SortBy != "DD" {
+		return fmt.Errorf("invalid sort order %q, expected R or DD", c.SortBy)
+	}
+	return nil
 }
 
 func NewScraper(config Config) *Scraper {
@@ -35,6 +69,9 @@ func NewScraper(config Config) *Scraper {
 	// r2592000 last month
 	// r7776000 last 3 months
 	// r31536000 last year
+	if config.BaseURL == "" {
+		config.BaseURL = linkedInBaseURL
+	}
 	if config.Timespan == "" {
 		config.Timespan = "r604800" // Default to last week
 	}
@@ -52,16 +89,79 @@ func NewScraper(config Config) *Scraper {
 	if config.RequestTimeout == 0 {
 		config.RequestTimeout = 30 * time.Second
 	}
+	if config.RequestInterval == 0 {
+		config.RequestInterval = 1 * time.Second
+	}
+	if config.MaxConcurrentRequests == 0 {
+		config.MaxConcurrentRequests = 2
+	}
+	if config.Selectors == nil {
+		config.Selectors = DefaultSelectorProfile()
+	}
+
+	// One client for all workers, so connections are reused and the rate is per host, not per worker
+	client := newSourceClient(utils.RetryConfig{
+		MaxRetries: config.MaxRetries,
+		BaseDelay:  config.BaseDelay,
+		MaxDelay:   config.MaxDelay,
+	}, config.RequestTimeout, utils.HostLimits{
+		Interval:      config.RequestInterval,
+		MaxConcurrent: config.MaxConcurrentRequests,
+	}, config.Breaker)
 
 	return &Scraper{
-		config: config,
+		sourceClient: client,
+		config:       config,
 	}
 }
 
+// Name returns the LinkedIn source name
+func (s *Scraper) Name() string {
+	return models.SourceLinkedIn
+}
+
+// ListJobs implements JobSource by scraping LinkedIn search result pages
+func (s *Scraper) ListJobs(ctx context.Context, numPages int, params models.SearchQuery, jobChan chan<- models.Job) error {
+	return s.ScrapeLinkedInJobsStreaming(ctx, numPages, jobChan, params)
+}
+
+// ListJobsFrom implements ResumableJobSource
+func (s *Scraper) ListJobsFrom(ctx context.Context, startPage, numPages int, params models.SearchQuery, jobChan chan<- models.Job, pageDone func(page int, jobs []models.Job) error) error {
+	return s.scrapePages(ctx, startPage, numPages, jobChan, params, pageDone)
+}
+
+// FetchJobDescription implements JobSource by scraping the LinkedIn job page
+func (s *Scraper) FetchJobDescription(ctx context.Context, job models.Job) (models.JobDescription, error) {
+	return s.fetchJobPage(ctx, job, false)
+}
+
+// SetRefreshPolicy implements RefreshingSource. Job pages are cached for days, longer than a
+// description may be kept, so cached pages older than the policy allows are revalidated.
+func (s *Scraper) SetRefreshPolicy(policy RefreshPolicy) {
+	s.refresh = policy
+}
+
+// RecheckJob implements RecheckableSource by scraping the LinkedIn job page again, bypassing the
+// cached copy
+func (s *Scraper) RecheckJob(ctx context.Context, job models.Job) (models.JobDescription, error) {
+	return s.fetchJobPage(ctx, job, true)
+}
+
 // ScrapeLinkedInJobsStreaming scrapes jobs page by page and sends them to channel immediately
 func (s *Scraper) ScrapeLinkedInJobsStreaming(ctx context.Context, numPages int, jobChan chan<- models.Job, params models.SearchQuery) error {
+	return s.scrapePages(ctx, 0, numPages, jobChan, params, nil)
+}
+
+func (s *Scraper) scrapePages(ctx context.Context, startPage, numPages int, jobChan chan<- models.Job, params models.SearchQuery, pageDone func(page int, jobs []models.Job) error) error {
+	if err := s.config.Validate(); err != nil {
+		return fmt.Errorf("invalid scraper config: %w", err)
+	}
+	if err := params.Validate(); err != nil {
+		return fmt.Errorf("invalid search query: %w", err)
+	}
+
 	// Process pages sequentially to send jobs immediately
-	for i := range numPages {
+	for i := startPage; i < numPages; i++ {
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
@@ -71,6 +171,12 @@ func (s *Scraper) ScrapeLinkedInJobsStreaming(ctx context.Context, numPages int,
 				return fmt.Errorf("error scraping page %d: %w", i, err)
 			}
 
+			if pageDone != nil {
+				if err := pageDone(i, jobs); err != nil {
+					return fmt.Errorf("error checkpointing page %d: %w", i, err)
+				}
+			}
+
 			// Send jobs to channel immediately
 			for _, job := range jobs {
 				select {
@@ -88,13 +194,7 @@ func (s *Scraper) ScrapeJobsWithContext(ctx context.Context, page int, params mo
 	jobs := make([]models.Job, 0, 10)
 	url := s.buildSearchURL(params, page)
 
-	retryableRequest := utils.NewRetryableHTTPRequest(utils.RetryConfig{
-		BaseDelay:  s.config.BaseDelay,
-		MaxDelay:   s.config.MaxDelay,
-		MaxRetries: s.config.MaxRetries,
-	})
-
-	res, err := retryableRequest.RetryableHTTPRequest(ctx, url, "GET", nil, nil)
+	res, err := s.get(ctx, url)
 	if err != nil {
 		fmt.Printf("Error fetching URL after retries: %v\n", err)
 		return jobs, err
@@ -107,14 +207,38 @@ func (s *Scraper) ScrapeJobsWithContext(ctx context.Context, page int, params mo
 		return jobs, err
 	}
 
-	doc.Find("li > div.base-card").Each(func(i int, s *goquery.Selection) {
-		job := models.Job{}
-		title := s.Find("[class*=_title]").Text()
+	selectors := s.config.Selectors
+	cards := selectors.Card.Find(doc.Selection)
+	if cards.Length() == 0 {
+		// Past the last page the search API answers with an empty body
+		if strings.TrimSpace(doc.Text()) == "" {
+			return jobs, nil
+		}
+		return jobs, &LayoutDriftError{Profile: selectors.Version, URL: url, Missing: []string{"card"}}
+	}
+
+	// Cards missing each required field, to tell a changed layout from a few incomplete cards
+	missing := make(map[string]int)
+
+	cards.Each(func(i int, s *goquery.Selection) {
+		job := models.Job{Source: models.SourceLinkedIn, WorkTypeFilter: params.FWT}
+		title := selectors.CardTitle.Find(s).Text()
 		job.Title = strings.TrimSpace(title)
-		job.Company = strings.TrimSpace(s.Find(".hidden-nested-link").Text())
-		job.CompanyLink = strings.TrimSpace(s.Find(".hidden-nested-link").AttrOr("href", ""))
-		job.Location = strings.TrimSpace(s.Find(".job-search-card__location").Text())
-		job.JobLink = strings.TrimSpace(s.Find("a.base-card__full-link").AttrOr("href", ""))
+		company := selectors.CardCompany.Find(s)
+		job.Company = strings.TrimSpace(company.Text())
+		job.CompanyLink = strings.TrimSpace(company.AttrOr("href", ""))
+		job.Location = strings.TrimSpace(selectors.CardLocation.Find(s).Text())
+		job.JobLink = strings.TrimSpace(selectors.CardLink.Find(s).AttrOr("href", ""))
+
+		for field, value := range map[string]string{"card_title": job.Title, "card_company": job.Company, "card_location": job.Location, "card_link": job.JobLink} {
+			if value == "" {
+				missing[field]++
+			}
+		}
+		if job.JobLink == "" {
+			return
+		}
+
 		jobId, err := extractJobIDFromURL(job.JobLink)
 		if err != nil {
 			fmt.Printf("Error extracting job ID from URL %s: %v\n", job.JobLink, err)
@@ -125,63 +249,208 @@ func (s *Scraper) ScrapeJobsWithContext(ctx context.Context, page int, params mo
 			fmt.Printf("Error extracting job ID from URL %s: %v\n", job.JobLink, err)
 		}
 		job.ID = jobIdInt
+		parseCardDetails(s, &job, selectors)
 
 		if job.Title != "" && job.Company != "" && job.Location != "" && job.JobLink != "" {
 			jobs = append(jobs, job)
 		}
 	})
 
+	if len(missing) > 0 {
+		fields := make([]string, 0, len(missing))
+		for field := range missing {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields)
+
+		if len(jobs) == 0 {
+			return jobs, &LayoutDriftError{Profile: selectors.Version, URL: url, Missing: fields}
+		}
+		fmt.Printf("Page %d: skipped %d of %d cards missing %s\n", page, cards.Length()-len(jobs), cards.Length(), strings.Join(fields, ", "))
+	}
+
 	return jobs, nil
 }
 
 func (s *Scraper) ScrapeJobDescriptionWithContext(ctx context.Context, job models.Job) (string, map[string]string, error) {
-	var jobDescription string
-	url := s.buildJobDescriptionSearchURL(job.JobLink)
+	jd, err := s.fetchJobPage(ctx, job, false)
+	if err != nil {
+		return "", map[string]string{}, err
+	}
+	if jd.Description == "" {
+		return "", map[string]string{}, fmt.Errorf("job description not found")
+	}
 
-	retryableRequest := utils.NewRetryableHTTPRequest(utils.RetryConfig{
-		MaxRetries: s.config.MaxRetries,
-		BaseDelay:  s.config.BaseDelay,
-		MaxDelay:   s.config.MaxDelay,
-	})
+	return jd.Description, jd.Criteria, nil
+}
+
+// fetchJobPage scrapes the job page of job. A closed posting is returned even when its page no
+// longer shows the description.
+func (s *Scraper) fetchJobPage(ctx context.Context, job models.Job, revalidate bool) (models.JobDescription, error) {
+	pageURL := s.buildJobDescriptionSearchURL(job.JobLink)
+	request := utils.NewRequest(http.MethodGet, pageURL)
+	switch {
+	case revalidate || s.refresh.RefetchAll:
+		request.WithRevalidation()
+	case s.refresh.MaxDescriptionAge > 0:
+		request.WithMaxAge(s.refresh.MaxDescriptionAge)
+	}
 
-	res, err := retryableRequest.RetryableHTTPRequest(ctx, url, "GET", nil, nil)
+	res, err := s.do(ctx, request)
 	if err != nil {
 		fmt.Printf("Error fetching job description URL after retries: %v\n", err)
-		return "", map[string]string{}, err
+		return models.JobDescription{}, err
 	}
 
 	defer res.Body.Close()
 
 	doc, err := goquery.NewDocumentFromReader(res.Body)
 	if err != nil {
-		return "", map[string]string{}, err
+		return models.JobDescription{}, err
 	}
 
+	closed, applicants := parsePostingStatus(doc, s.config.Selectors)
+
 	// Find the job details section directly by ID and extract its text content
-	jobDescription, jobCriteria, err := s.parseJobDescription(doc)
-	if err != nil {
-		return "", map[string]string{}, err
+	jobDescription, jobCriteria, err := s.parseJobDescription(doc, pageURL)
+	if err != nil && !closed {
+		return models.JobDescription{}, err
 	}
 
-	return strings.TrimSpace(jobDescription), jobCriteria, nil
+	return models.JobDescription{
+		JobID:               job.ID,
+		Source:              job.Source,
+		Description:         jobDescription.Text,
+		DescriptionMarkdown: jobDescription.Markdown,
+		DescriptionHTML:     jobDescription.HTML,
+		Criteria:            jobCriteria,
+		Closed:              closed,
+		ApplicantCount:      applicants,
+	}, nil
+}
+
+// closedPostingTexts are shown in the top card of postings that no longer accept applications
+var closedPostingTexts = []string{
+	"No longer accepting applications",
+	"現在応募を受け付けていません",
+	"応募の受付は終了しました",
+}
+
+var applicantCountPattern = regexp.MustCompile(`\d[\d,]*`)
+
+// parsePostingStatus reads whether the posting is closed and the number of applicants it shows,
+// e.g. 200 for "Over 200 applicants"
+func parsePostingStatus(doc *goquery.Document, selectors *SelectorProfile) (bool, int) {
+	closed := selectors.ClosedPosting.Find(doc.Selection).Length() > 0
+	if !closed {
+		topCard := selectors.TopCard.Find(doc.Selection).Text()
+		for _, text := range closedPostingTexts {
+			if strings.Contains(topCard, text) {
+				closed = true
+				break
+			}
+		}
+	}
+
+	applicants := 0
+	caption := selectors.ApplicantCount.Find(doc.Selection).First().Text()
+	if digits := applicantCountPattern.FindString(caption); digits != "" {
+		applicants, _ = strconv.Atoi(strings.ReplaceAll(digits, ",", ""))
+	}
+
+	return closed, applicants
+}
+
+// parseCardDetails reads the optional details of a search result card into job
+func parseCardDetails(card *goquery.Selection, job *models.Job, selectors *SelectorProfile) {
+	job.URN = strings.TrimSpace(card.AttrOr("data-entity-urn", ""))
+
+	if datetime, ok := selectors.CardDate.Find(card).First().Attr("datetime"); ok {
+		if posted, err := parsePostedDate(datetime); err == nil {
+			job.PostedAt = &posted
+		} else {
+			fmt.Printf("Error parsing posted date %q of job %d: %v\n", datetime, job.ID, err)
+		}
+	}
+
+	job.SalaryInsight = strings.Join(strings.Fields(selectors.CardSalary.Find(card).Text()), " ")
+
+	selectors.CardBadges.Find(card).Each(func(i int, badge *goquery.Selection) {
+		if text := strings.Join(strings.Fields(badge.Text()), " "); text != "" {
+			job.Badges = append(job.Badges, text)
+		}
+	})
+
+	// Logos are lazy loaded, the real URL is in data-delayed-url until the image is shown
+	logo := selectors.CardLogo.Find(card).First()
+	job.CompanyLogo = strings.TrimSpace(logo.AttrOr("data-delayed-url", logo.AttrOr("src", "")))
+	if strings.HasPrefix(job.CompanyLogo, "data:") {
+		job.CompanyLogo = ""
+	}
+}
+
+// parsePostedDate reads the datetime attribute of a card, either a date or a full timestamp,
+// and returns it in UTC
+func parsePostedDate(datetime string) (time.Time, error) {
+	datetime = strings.TrimSpace(datetime)
+	for _, layout := range []string{time.DateOnly, time.RFC3339, "2006-01-02T15:04:05"} {
+		if posted, err := time.Parse(layout, datetime); err == nil {
+			return posted.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unknown date format")
 }
 
 func (s *Scraper) buildSearchURL(query models.SearchQuery, page int) string {
-	baseURL := "https://www.linkedin.com/jobs-guest/jobs/api/seeMoreJobPostings/search"
+	baseURL := strings.TrimRight(s.config.BaseURL, "/") + "/jobs-guest/jobs/api/seeMoreJobPostings/search"
 	params := url.Values{}
 
-	// Required search parameters
-	params.Set("keywords", url.QueryEscape(query.Keywords))
-	params.Set("location", url.QueryEscape(query.Location))
+	// Required search parameters, Encode takes care of escaping
+	params.Set("keywords", query.Keywords)
+	params.Set("location", query.Location)
+
+	if query.GeoId != "" {
+		params.Set("geoId", query.GeoId)
+	}
 
 	// Time filter
-	// params.Set("f_TPR", s.config.Timespan)
+	if s.config.Timespan != "" {
+		params.Set("f_TPR", s.config.Timespan)
+	}
+
+	if s.config.Distance != "" {
+		params.Set("distance", s.config.Distance)
+	}
+
+	if s.config.SortBy != "" {
+		params.Set("sortBy", s.config.SortBy)
+	}
 
 	// Work type filter
 	if query.FWT != "" {
 		params.Set("f_WT", query.FWT)
 	}
 
+	// Experience level, job type and company filters
+	if f := query.ExperienceLevelFilter(); f != "" {
+		params.Set("f_E", f)
+	}
+	if f := query.JobTypeFilter(); f != "" {
+		params.Set("f_JT", f)
+	}
+	if len(query.CompanyIDs) > 0 {
+		params.Set("f_C", strings.Join(query.CompanyIDs, ","))
+	}
+
+	if query.EasyApply {
+		params.Set("f_AL", "true")
+	}
+
+	// Under 10 applicants
+	if query.FewApplicants {
+		params.Set("f_EA", "true")
+	}
+
 	// Pagination
 	params.Set("start", strconv.Itoa(25*page))
 
@@ -190,50 +459,52 @@ func (s *Scraper) buildSearchURL(query models.SearchQuery, page int) string {
 
 func (s *Scraper) buildJobDescriptionSearchURL(jobLink string) string {
 	// For job descriptions, we should use the direct job link
-	return strings.ReplaceAll(jobLink, "jp.linkedin.com", "linkedin.com")
+	jobLink = strings.ReplaceAll(jobLink, "jp.linkedin.com", "linkedin.com")
+
+	// Job links always point at linkedin.com, send them to the configured backend instead
+	if s.config.BaseURL != linkedInBaseURL {
+		if parsed, err := url.Parse(jobLink); err == nil {
+			return strings.TrimRight(s.config.BaseURL, "/") + parsed.RequestURI()
+		}
+	}
+	return jobLink
 }
 
-func (s *Scraper) parseJobDescription(doc *goquery.Document) (string, map[string]string, error) {
-	var jobDescription string
+// parseJobDescription reads the description and the criteria of a job page, a page missing the
+// description section or its markup is reported as a *LayoutDriftError
+func (s *Scraper) parseJobDescription(doc *goquery.Document, pageURL string) (formattedDescription, map[string]string, error) {
+	var jobDescription formattedDescription
 	jobCriteria := make(map[string]string)
+	selectors := s.config.Selectors
 
 	// Find the main description section
-	descriptionSection := doc.Find("section.core-section-container.description .core-section-container__content")
-
+	descriptionSection := selectors.Description.Find(doc.Selection)
 	if descriptionSection.Length() == 0 {
-		// Alternative selector if classes are different
-		descriptionSection = doc.Find("section.description .core-section-container__content")
+		return jobDescription, jobCriteria, &LayoutDriftError{Profile: selectors.Version, URL: pageURL, Missing: []string{"description"}}
 	}
 
 	// Parse the main job description from show-more-less-html section
-	descriptionHTML := descriptionSection.Find("section.show-more-less-html .show-more-less-html__markup")
-
-	if descriptionHTML.Length() > 0 {
-		jobDescription += parseHTMLContent(descriptionHTML)
+	descriptionHTML := selectors.DescriptionMarkup.Find(descriptionSection)
+	if descriptionHTML.Length() == 0 {
+		return jobDescription, jobCriteria, &LayoutDriftError{Profile: selectors.Version, URL: pageURL, Missing: []string{"description_markup"}}
 	}
+	jobDescription = formatDescription(descriptionHTML)
 
 	// Parse job criteria list
-	criteriaList := descriptionSection.Find("ul.description__job-criteria-list")
-
-	if criteriaList.Length() > 0 {
-		criteriaList.Find("li.description__job-criteria-item").Each(func(i int, li *goquery.Selection) {
-			// Get the criteria header
-			header := strings.TrimSpace(li.Find("h3.description__job-criteria-subheader").Text())
-
-			// Get the criteria value
-			value := strings.TrimSpace(li.Find("span.description__job-criteria-text").Text())
+	selectors.CriteriaItem.Find(descriptionSection).Each(func(i int, li *goquery.Selection) {
+		// Get the criteria header
+		header := strings.TrimSpace(selectors.CriteriaHeader.Find(li).Text())
 
-			if header != "" && value != "" {
-				jobCriteria[header] = value
-			}
-		})
-	}
+		// Get the criteria value
+		value := strings.TrimSpace(selectors.CriteriaValue.Find(li).Text())
 
-	// Clean up the description
-	jobDescription = strings.TrimSpace(jobDescription)
+		if header != "" && value != "" {
+			jobCriteria[header] = value
+		}
+	})
 
-	if jobDescription == "" {
-		return "", jobCriteria, fmt.Errorf("job description not found")
+	if jobDescription.Text == "" {
+		return formattedDescription{}, jobCriteria, fmt.Errorf("job description not found")
 	}
 
 	return jobDescription, jobCriteria, nil
@@ -242,73 +513,135 @@ func (s *Scraper) parseJobDescription(doc *goquery.Document) (string, map[string
 // parseHTMLContent walks through HTML elements in order and preserves formatting
 func parseHTMLContent(selection *goquery.Selection) string {
 	var result strings.Builder
+	writePlainText(&result, selection, 0)
+	return result.String()
+}
 
-	// Walk through all child nodes in order
+// writePlainText writes the children of selection as plain text. Elements are walked recursively
+// so line breaks and nested lists inside paragraphs and items keep their own lines, depth is the
+// list nesting level.
+func writePlainText(result *strings.Builder, selection *goquery.Selection, depth int) {
 	selection.Contents().Each(func(i int, s *goquery.Selection) {
-		if goquery.NodeName(s) == "#text" {
-			// Handle text nodes
-			text := strings.TrimSpace(s.Text())
-			if text != "" {
-				result.WriteString(text)
+		tagName := goquery.NodeName(s)
+
+		switch {
+		case tagName == "#text":
+			// Keep the space that separates text from inline siblings
+			text := collapseWhitespace(s.Text())
+			if atLineStart(result) || strings.HasSuffix(result.String(), " ") {
+				text = strings.TrimLeft(text, " ")
 			}
-		} else {
-			// Handle element nodes
-			tagName := goquery.NodeName(s)
-			text := strings.TrimSpace(s.Text())
-
-			if text == "" {
-				return
+			result.WriteString(text)
+			return
+		case tagName == "br":
+			// Line breaks have no text of their own, and never open more than one blank line, e.g.
+			// right after a list
+			trimTrailingSpaces(result)
+			if !strings.HasSuffix(result.String(), "\n\n") {
+				result.WriteString("\n")
 			}
+			return
+		case ignoredElements[tagName] || strings.TrimSpace(s.Text()) == "":
+			return
+		}
 
-			switch tagName {
-			case "p":
-				result.WriteString(text)
-				result.WriteString("\n\n")
-			case "h1", "h2", "h3", "h4", "h5", "h6":
-				result.WriteString(text)
-				result.WriteString("\n\n")
-			case "strong", "b":
-				result.WriteString("**")
-				result.WriteString(text)
-				result.WriteString("**")
-			case "em", "i":
-				result.WriteString("*")
-				result.WriteString(text)
-				result.WriteString("*")
-			case "li":
-				result.WriteString("• ")
-				result.WriteString(text)
-				result.WriteString("\n")
-			case "ul", "ol":
-				// Process list items recursively
-				s.Find("li").Each(func(j int, li *goquery.Selection) {
-					liText := strings.TrimSpace(li.Text())
-					if liText != "" {
-						result.WriteString("• ")
-						result.WriteString(liText)
-						result.WriteString("\n")
-					}
-				})
-				result.WriteString("\n")
-			case "br":
-				result.WriteString("\n")
-			case "div", "span":
-				// For div and span, recursively parse content
-				if s.Children().Length() > 0 {
-					result.WriteString(parseHTMLContent(s))
-				} else {
-					result.WriteString(text)
-					result.WriteString(" ")
+		switch tagName {
+		case "p", "h1", "h2", "h3", "h4", "h5", "h6":
+			startParagraph(result)
+			writePlainText(result, s, depth)
+			trimTrailingSpaces(result)
+			result.WriteString("\n\n")
+		case "table":
+			// Rows keep their own lines, the table is set apart like a paragraph
+			startParagraph(result)
+			writePlainText(result, s, depth)
+			startParagraph(result)
+		case "div", "section", "article", "header", "footer", "blockquote", "pre", "tr":
+			startLine(result)
+			writePlainText(result, s, depth)
+			startLine(result)
+		case "strong", "b":
+			result.WriteString(wrapPlainText(s, depth, "**"))
+		case "em", "i":
+			result.WriteString(wrapPlainText(s, depth, "*"))
+		case "ul", "ol":
+			startLine(result)
+			s.ChildrenFiltered("li").Each(func(j int, li *goquery.Selection) {
+				marker := "•"
+				if tagName == "ol" {
+					marker = strconv.Itoa(j+1) + "."
 				}
-			default:
-				// For other tags, just extract text content
-				result.WriteString(text)
-				result.WriteString(" ")
+				writeListItem(result, li, depth, marker)
+			})
+			if depth == 0 {
+				result.WriteString("\n")
 			}
+		case "li":
+			startLine(result)
+			writeListItem(result, s, depth, "•")
+		case "td", "th":
+			writePlainText(result, s, depth)
+			result.WriteString(" ")
+		default:
+			writePlainText(result, s, depth)
 		}
 	})
+}
 
-	return result.String()
+// writeListItem writes li after marker, a bullet or its number, indented by depth, with its nested
+// lists on their own lines below it
+func writeListItem(result *strings.Builder, li *goquery.Selection, depth int, marker string) {
+	var item strings.Builder
+	writePlainText(&item, li, depth+1)
+	text := strings.TrimSpace(item.String())
+	if text == "" {
+		return
+	}
+
+	result.WriteString(strings.Repeat("  ", depth))
+	result.WriteString(marker + " ")
+	result.WriteString(text)
+	result.WriteString("\n")
+}
+
+// wrapPlainText writes the content of s between marker, e.g. **bold**
+func wrapPlainText(s *goquery.Selection, depth int, marker string) string {
+	var inner strings.Builder
+	writePlainText(&inner, s, depth)
+	text := strings.TrimSpace(inner.String())
+	if text == "" {
+		return ""
+	}
+	return marker + text + marker
+}
+
+func atLineStart(result *strings.Builder) bool {
+	current := result.String()
+	return current == "" || strings.HasSuffix(current, "\n")
+}
+
+// startLine ends the current line unless nothing was written on it yet
+func startLine(result *strings.Builder) {
+	if !atLineStart(result) {
+		trimTrailingSpaces(result)
+		result.WriteString("\n")
+	}
+}
+
+// startParagraph leaves a blank line after whatever was written before
+func startParagraph(result *strings.Builder) {
+	trimTrailingSpaces(result)
+	for result.Len() > 0 && !strings.HasSuffix(result.String(), "\n\n") {
+		result.WriteString("\n")
+	}
+}
+
+func trimTrailingSpaces(result *strings.Builder) {
+	current := result.String()
+	if trimmed := strings.TrimRight(current, " "); len(trimmed) != len(current) {
+		result.Reset()
+		result.WriteString(trimmed)
+	}
 }
 
 func extractJobIDFromURL(jobURL string) (string, error) {
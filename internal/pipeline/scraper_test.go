@@ -0,0 +1,363 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jobs-scraper/internal/models"
+	"github.com/jobs-scraper/internal/utils"
+)
+
+const fakeJobPage = `<html><body>
+<section class="core-section-container description"><div class="core-section-container__content">
+<section class="show-more-less-html"><div class="show-more-less-html__markup"><p>Write Go services.</p></div></section>
+</div></section>
+</body></html>`
+
+// fakeSearchPage returns a search result page listing two jobs, numbered after the start offset
+func fakeSearchPage(start int) string {
+	page := ""
+	for i := 1; i <= 2; i++ {
+		id := 4300000000 + start + i
+		page += fmt.Sprintf(`<li><div class="base-card">
+<a class="base-card__full-link" href="https://jp.linkedin.com/jobs/view/go-developer-%d?position=%d"></a>
+<h3 class="base-search-card__title">Go Developer</h3>
+<h4 class="base-search-card__subtitle"><a class="hidden-nested-link" href="https://jp.linkedin.com/company/acme">Acme</a></h4>
+<span class="job-search-card__location">Tokyo, Japan</span>
+</div></li>`, id, i)
+	}
+	return page
+}
+
+// newTestScraper points a scraper at server without the delays meant for LinkedIn
+func newTestScraper(server *httptest.Server) *Scraper {
+	return NewScraper(Config{
+		BaseURL:               server.URL,
+		MaxRetries:            1,
+		BaseDelay:             time.Millisecond,
+		MaxDelay:              time.Millisecond,
+		RequestInterval:       time.Millisecond,
+		MaxConcurrentRequests: 10,
+	})
+}
+
+func TestGetJobsRequestsEveryPageOfTheQuery(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		searches []url.Values
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/jobs-guest/jobs/api/seeMoreJobPostings/search" {
+			http.NotFound(w, r)
+			return
+		}
+		mu.Lock()
+		searches = append(searches, r.URL.Query())
+		mu.Unlock()
+
+		start, _ := strconv.Atoi(r.URL.Query().Get("start"))
+		fmt.Fprint(w, fakeSearchPage(start))
+	}))
+	defer server.Close()
+
+	query := models.SearchQuery{Keywords: "Go Developer", Location: "Japan", FWT: "2"}
+	matchChan, errChan := GetJobs(context.Background(), []JobSource{newTestScraper(server)}, 3, []models.SearchQuery{query}, noCheckpoints{})
+	var jobs []models.Job
+	for match := range matchChan {
+		if !reflect.DeepEqual(match.Query, query) {
+			t.Errorf("job %d tagged with query %+v, want %+v", match.Job.ID, match.Query, query)
+		}
+		jobs = append(jobs, match.Job)
+	}
+	for err := range errChan {
+		t.Errorf("listing: %v", err)
+	}
+
+	var starts []string
+	for _, search := range searches {
+		starts = append(starts, search.Get("start"))
+		for param, want := range map[string]string{"keywords": "Go Developer", "location": "Japan", "f_WT": "2"} {
+			if got := search.Get(param); got != want {
+				t.Errorf("search page start=%s has %s=%q, want %q", search.Get("start"), param, got, want)
+			}
+		}
+	}
+	if want := []string{"0", "25", "50"}; !reflect.DeepEqual(starts, want) {
+		t.Errorf("requested search pages %v, want %v", starts, want)
+	}
+
+	if len(jobs) != 6 {
+		t.Fatalf("listed %d jobs, want 6", len(jobs))
+	}
+	if jobs[0].ID != 4300000001 || jobs[5].ID != 4300000052 {
+		t.Errorf("listed jobs %d to %d, want 4300000001 to 4300000052", jobs[0].ID, jobs[5].ID)
+	}
+}
+
+func TestGetJobsReportsLayoutDrift(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<div class="job-card-v2">Go Developer</div>`)
+	}))
+	defer server.Close()
+
+	query := models.SearchQuery{Keywords: "Go Developer", Location: "Japan"}
+	matchChan, errChan := GetJobs(context.Background(), []JobSource{newTestScraper(server)}, 3, []models.SearchQuery{query}, noCheckpoints{})
+	for match := range matchChan {
+		t.Errorf("listed job %d from a page without cards", match.Job.ID)
+	}
+
+	err := listingError(errChan)
+	var drift *LayoutDriftError
+	if !errors.As(err, &drift) {
+		t.Fatalf("listing error %v, want a *LayoutDriftError", err)
+	}
+	if !reflect.DeepEqual(drift.Missing, []string{"card"}) {
+		t.Errorf("drift missing %v, want [card]", drift.Missing)
+	}
+}
+
+func TestListingErrorPrefersLayoutDrift(t *testing.T) {
+	drift := &LayoutDriftError{Profile: "test", URL: "https://example.com", Missing: []string{"card"}}
+
+	errChan := make(chan error, 2)
+	errChan <- errors.New("connection reset")
+	errChan <- fmt.Errorf("listing linkedin jobs: %w", drift)
+	close(errChan)
+
+	err := listingError(errChan)
+	if !errors.Is(err, drift) {
+		t.Errorf("listing error %v, want the layout drift", err)
+	}
+
+	empty := make(chan error)
+	close(empty)
+	if err := listingError(empty); err != nil {
+		t.Errorf("listing error %v without failed listings", err)
+	}
+}
+
+func TestGetJobDescriptionFetchesWithNumWorkers(t *testing.T) {
+	const numWorkers = 3
+
+	var (
+		mu          sync.Mutex
+		inFlight    int
+		maxInFlight int
+		reachedOnce sync.Once
+	)
+	// Requests wait until numWorkers are in flight at once, so fewer workers fail by timing out
+	reached := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		inFlight++
+		maxInFlight = max(maxInFlight, inFlight)
+		if inFlight == numWorkers {
+			reachedOnce.Do(func() { close(reached) })
+		}
+		mu.Unlock()
+
+		select {
+		case <-reached:
+		case <-time.After(2 * time.Second):
+		}
+		fmt.Fprint(w, fakeJobPage)
+
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	jobChan := make(chan models.Job, 10)
+	for i := 1; i <= 2*numWorkers; i++ {
+		jobChan <- models.Job{
+			ID:      int64(i),
+			Source:  models.SourceLinkedIn,
+			JobLink: fmt.Sprintf("https://jp.linkedin.com/jobs/view/go-developer-%d", i),
+		}
+	}
+	close(jobChan)
+
+	fetched := 0
+	for item := range GetJobDescription(context.Background(), []JobSource{newTestScraper(server)}, jobChan, numWorkers) {
+		if item.JobDescription.Description != "Write Go services." {
+			t.Errorf("job %d has description %q", item.Job.ID, item.JobDescription.Description)
+		}
+		fetched++
+	}
+
+	if fetched != 2*numWorkers {
+		t.Errorf("fetched %d descriptions, want %d", fetched, 2*numWorkers)
+	}
+	select {
+	case <-reached:
+	default:
+		t.Errorf("never had %d job pages in flight at once", numWorkers)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if maxInFlight != numWorkers {
+		t.Errorf("at most %d job pages were in flight at once, want %d", maxInFlight, numWorkers)
+	}
+}
+
+func TestGetJobDescriptionMarksClosedPostingWithoutDescription(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><body><section class="top-card-layout"><figure class="closed-job">No longer accepting applications</figure></section></body></html>`)
+	}))
+	defer server.Close()
+
+	jobChan := make(chan models.Job, 1)
+	jobChan <- models.Job{ID: 1, Source: models.SourceLinkedIn, JobLink: "https://jp.linkedin.com/jobs/view/go-developer-1"}
+	close(jobChan)
+
+	var items []models.JobWithDescription
+	for item := range GetJobDescription(context.Background(), []JobSource{newTestScraper(server)}, jobChan, 1) {
+		items = append(items, item)
+	}
+
+	if len(items) != 1 {
+		t.Fatalf("forwarded %d jobs, want the closed one", len(items))
+	}
+	item := items[0]
+	if item.Job.ClosedAt == nil || !item.JobDescription.Closed {
+		t.Errorf("job closed at %v with description closed %v, want both closed", item.Job.ClosedAt, item.JobDescription.Closed)
+	}
+	// The batch store keeps the stored description of a posting without one
+	if item.JobDescription.Description != "" {
+		t.Errorf("closed posting has description %q", item.JobDescription.Description)
+	}
+}
+
+// shiftedClock is the system clock moved forward by offset, to age cached responses
+type shiftedClock struct {
+	offset time.Duration
+}
+
+func (c *shiftedClock) Now() time.Time { return time.Now().Add(c.offset) }
+
+func (c *shiftedClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+func TestFetchJobDescriptionRefreshReachesTheServer(t *testing.T) {
+	tests := []struct {
+		name      string
+		policy    RefreshPolicy
+		age       time.Duration // Age of the cached page at the second fetch
+		wantFetch int           // Requests that reached the server over both fetches
+	}{
+		{name: "fresh cached page", age: 4 * 24 * time.Hour, wantFetch: 1},
+		{name: "refetch all", policy: RefreshPolicy{RefetchAll: true}, wantFetch: 2},
+		{name: "page older than the refresh age", policy: RefreshPolicy{MaxDescriptionAge: 3 * 24 * time.Hour}, age: 4 * 24 * time.Hour, wantFetch: 2},
+		{name: "page within the refresh age", policy: RefreshPolicy{MaxDescriptionAge: 3 * 24 * time.Hour}, age: 2 * 24 * time.Hour, wantFetch: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fetched atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fetched.Add(1)
+				fmt.Fprint(w, fakeJobPage)
+			}))
+			defer server.Close()
+
+			clock := &shiftedClock{}
+			cache, err := utils.NewResponseCache(utils.CacheConfig{
+				Dir:    t.TempDir(),
+				Routes: []utils.CacheRoute{utils.NewCacheRoute(`/jobs/view/`, 7*24*time.Hour)},
+				Clock:  clock,
+			})
+			if err != nil {
+				t.Fatalf("NewResponseCache: %v", err)
+			}
+
+			scraper := newTestScraper(server)
+			pipeline := NewJobPipeline(1, 0, scraper)
+			pipeline.SetResponseCache(cache)
+			pipeline.SetRefreshPolicy(tt.policy)
+
+			job := models.Job{ID: 1, Source: models.SourceLinkedIn, JobLink: "https://jp.linkedin.com/jobs/view/go-developer-1"}
+			for i, offset := range []time.Duration{0, tt.age} {
+				clock.offset = offset
+				jd, err := scraper.FetchJobDescription(context.Background(), job)
+				if err != nil {
+					t.Fatalf("fetch %d: %v", i+1, err)
+				}
+				if jd.Description != "Write Go services." {
+					t.Errorf("fetch %d has description %q", i+1, jd.Description)
+				}
+			}
+
+			if got := int(fetched.Load()); got != tt.wantFetch {
+				t.Errorf("server answered %d of 2 fetches, want %d", got, tt.wantFetch)
+			}
+		})
+	}
+}
+
+func TestBuildSearchURL(t *testing.T) {
+	const search = "https://www.linkedin.com/jobs-guest/jobs/api/seeMoreJobPostings/search?"
+
+	tests := []struct {
+		name   string
+		config Config
+		query  models.SearchQuery
+		page   int
+		want   string
+	}{
+		{
+			name:  "defaults",
+			query: models.SearchQuery{Keywords: "Frontend Developer", Location: "Japan"},
+			want:  search + "f_TPR=r604800&keywords=Frontend+Developer&location=Japan&start=0",
+		},
+		{
+			name:   "config filters and later page",
+			config: Config{Timespan: "r86400", Distance: "25", SortBy: "DD"},
+			query:  models.SearchQuery{Keywords: "Go", Location: "Tokyo", GeoId: "101355337", FWT: "2,3"},
+			page:   2,
+			want:   search + "distance=25&f_TPR=r86400&f_WT=2%2C3&geoId=101355337&keywords=Go&location=Tokyo&sortBy=DD&start=50",
+		},
+		{
+			name: "every query filter",
+			query: models.SearchQuery{
+				Keywords:         "Backend",
+				Location:         "Japan",
+				ExperienceLevels: []models.ExperienceLevel{models.ExperienceEntryLevel, models.ExperienceMidSenior},
+				JobTypes:         []models.JobType{models.JobTypeFullTime, models.JobTypeContract},
+				CompanyIDs:       []string{"1441", "1586"},
+				EasyApply:        true,
+				FewApplicants:    true,
+			},
+			page: 1,
+			want: search + "f_AL=true&f_C=1441%2C1586&f_E=2%2C4&f_EA=true&f_JT=F%2CC&f_TPR=r604800&keywords=Backend&location=Japan&start=25",
+		},
+		{
+			name:  "keywords and location are escaped",
+			query: models.SearchQuery{Keywords: "C++ & Go/Rust", Location: "São Paulo"},
+			want:  search + "f_TPR=r604800&keywords=C%2B%2B+%26+Go%2FRust&location=S%C3%A3o+Paulo&start=0",
+		},
+		{
+			name:   "base URL of a fake backend",
+			config: Config{BaseURL: "http://127.0.0.1:8080/"},
+			query:  models.SearchQuery{Keywords: "Go", Location: "Japan"},
+			want:   "http://127.0.0.1:8080/jobs-guest/jobs/api/seeMoreJobPostings/search?f_TPR=r604800&keywords=Go&location=Japan&start=0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewScraper(tt.config).buildSearchURL(tt.query, tt.page); got != tt.want {
+				t.Errorf("buildSearchURL\n got %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
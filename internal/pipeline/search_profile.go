@@ -0,0 +1,140 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/jobs-scraper/internal/models"
+)
+
+// MatchedJob is a listed job together with the search query that listed it
+type MatchedJob struct {
+	Job   models.Job
+	Query models.SearchQuery
+}
+
+// jobKey identifies a job across all sources
+type jobKey struct {
+	source string
+	id     int64
+}
+
+// QueryMatches records which queries listed each job during a run
+type QueryMatches struct {
+	mu      sync.Mutex
+	queries map[string]models.SearchQuery
+	jobs    map[string][]jobKey // Jobs listed by each query, keyed by query key
+	seen    map[jobKey]struct{}
+}
+
+func NewQueryMatches() *QueryMatches {
+	return &QueryMatches{
+		queries: make(map[string]models.SearchQuery),
+		jobs:    make(map[string][]jobKey),
+		seen:    make(map[jobKey]struct{}),
+	}
+}
+
+// add records the match and reports whether the job was seen for the first time
+func (m *QueryMatches) add(match MatchedJob) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := jobKey{source: match.Job.Source, id: match.Job.ID}
+	queryKey := match.Query.Key()
+
+	m.queries[queryKey] = match.Query
+	m.jobs[queryKey] = append(m.jobs[queryKey], key)
+
+	if _, ok := m.seen[key]; ok {
+		return false
+	}
+	m.seen[key] = struct{}{}
+	return true
+}
+
+// ForJobs returns, for each query, the jobs among the given ones it listed
+func (m *QueryMatches) ForJobs(jobs []models.Job) map[string][]models.Job {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	byKey := make(map[jobKey]models.Job, len(jobs))
+	for _, job := range jobs {
+		byKey[jobKey{source: job.Source, id: job.ID}] = job
+	}
+
+	result := make(map[string][]models.Job)
+	for queryKey, keys := range m.jobs {
+		added := make(map[jobKey]struct{}, len(keys))
+		for _, key := range keys {
+			job, ok := byKey[key]
+			if _, dup := added[key]; !ok || dup {
+				continue
+			}
+			added[key] = struct{}{}
+			result[queryKey] = append(result[queryKey], job)
+		}
+	}
+
+	return result
+}
+
+// Query returns the query recorded under key
+func (m *QueryMatches) Query(key string) models.SearchQuery {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.queries[key]
+}
+
+// DedupeJobs forwards each job only the first time any query lists it, recording every match
+func DedupeJobs(ctx context.Context, matchChan <-chan MatchedJob, matches *QueryMatches) <-chan models.Job {
+	jobChan := make(chan models.Job, 100)
+
+	go func() {
+		defer close(jobChan)
+		for match := range matchChan {
+			if !matches.add(match) {
+				continue
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case jobChan <- match.Job:
+			}
+		}
+	}()
+
+	return jobChan
+}
+
+// LoadSearchProfile reads a search profile from a JSON file
+func LoadSearchProfile(path string) (models.SearchProfile, error) {
+	var profile models.SearchProfile
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return profile, fmt.Errorf("error reading search profile %s: %w", path, err)
+	}
+
+	if err := json.Unmarshal(data, &profile); err != nil {
+		return profile, fmt.Errorf("error decoding search profile %s: %w", path, err)
+	}
+
+	if len(profile.Queries) == 0 {
+		return profile, fmt.Errorf("search profile %s has no queries", path)
+	}
+	for i, query := range profile.Queries {
+		if err := query.Validate(); err != nil {
+			return profile, fmt.Errorf("search profile %s query %d: %w", path, i+1, err)
+		}
+	}
+	if profile.Pages <= 0 {
+		profile.Pages = 1
+	}
+
+	return profile, nil
+}
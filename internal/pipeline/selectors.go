@@ -0,0 +1,150 @@
+package pipeline
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/andybalholm/cascadia"
+)
+
+//go:embed selectors/linkedin.json
+var defaultSelectorProfileJSON []byte
+
+// SelectorChain lists CSS selectors from the current markup to older or alternative ones, the
+// first selector that matches wins
+type SelectorChain []string
+
+// Find returns the matches of the first selector of the chain that matches within s
+func (c SelectorChain) Find(s *goquery.Selection) *goquery.Selection {
+	for _, selector := range c {
+		if found := s.Find(selector); found.Length() > 0 {
+			return found
+		}
+	}
+	return s.Slice(0, 0)
+}
+
+// SelectorProfile holds every CSS selector the LinkedIn scraper reads pages with. Card fields are
+// looked up within a card, criteria fields within a criteria item and the others within the page.
+type SelectorProfile struct {
+	Version string `json:"version"`
+
+	Card         SelectorChain `json:"card"`
+	CardTitle    SelectorChain `json:"card_title"`
+	CardCompany  SelectorChain `json:"card_company"`
+	CardLocation SelectorChain `json:"card_location"`
+	CardLink     SelectorChain `json:"card_link"`
+	CardDate     SelectorChain `json:"card_date"`
+	CardSalary   SelectorChain `json:"card_salary"`
+	CardBadges   SelectorChain `json:"card_badges"`
+	CardLogo     SelectorChain `json:"card_logo"`
+
+	Description       SelectorChain `json:"description"`
+	DescriptionMarkup SelectorChain `json:"description_markup"` // Within Description
+	CriteriaItem      SelectorChain `json:"criteria_item"`      // Within Description
+	CriteriaHeader    SelectorChain `json:"criteria_header"`
+	CriteriaValue     SelectorChain `json:"criteria_value"`
+	ClosedPosting     SelectorChain `json:"closed_posting"`
+	TopCard           SelectorChain `json:"top_card"`
+	ApplicantCount    SelectorChain `json:"applicant_count"`
+}
+
+// DefaultSelectorProfile returns the selector profile shipped with the scraper
+func DefaultSelectorProfile() *SelectorProfile {
+	profile, err := parseSelectorProfile(defaultSelectorProfileJSON)
+	if err != nil {
+		panic(fmt.Sprintf("invalid default selector profile: %v", err))
+	}
+	return profile
+}
+
+// LoadSelectorProfile reads a selector profile from a JSON file, e.g. a copy of
+// internal/pipeline/selectors/linkedin.json updated after LinkedIn changed its markup
+func LoadSelectorProfile(path string) (*SelectorProfile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading selector profile %s: %w", path, err)
+	}
+
+	profile, err := parseSelectorProfile(data)
+	if err != nil {
+		return nil, fmt.Errorf("selector profile %s: %w", path, err)
+	}
+	return profile, nil
+}
+
+func parseSelectorProfile(data []byte) (*SelectorProfile, error) {
+	var profile SelectorProfile
+	if err := json.Unmarshal(data, &profile); err != nil {
+		return nil, fmt.Errorf("error decoding selector profile: %w", err)
+	}
+	if err := profile.Validate(); err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+// requiredSelectors are the profile fields without which no job can be scraped
+var requiredSelectors = []string{"card", "card_title", "card_company", "card_link", "description", "description_markup"}
+
+// Validate checks that the profile has a version, that the fields the scraper cannot work without
+// have selectors and that every selector parses
+func (p *SelectorProfile) Validate() error {
+	if p.Version == "" {
+		return fmt.Errorf("selector profile has no version")
+	}
+
+	chains := p.chains()
+	for _, name := range requiredSelectors {
+		if len(chains[name]) == 0 {
+			return fmt.Errorf("selector profile %s has no %s selectors", p.Version, name)
+		}
+	}
+
+	for name, chain := range chains {
+		for _, selector := range chain {
+			if _, err := cascadia.ParseGroup(selector); err != nil {
+				return fmt.Errorf("selector profile %s has an invalid %s selector %q: %w", p.Version, name, selector, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (p *SelectorProfile) chains() map[string]SelectorChain {
+	return map[string]SelectorChain{
+		"card":               p.Card,
+		"card_title":         p.CardTitle,
+		"card_company":       p.CardCompany,
+		"card_location":      p.CardLocation,
+		"card_link":          p.CardLink,
+		"card_date":          p.CardDate,
+		"card_salary":        p.CardSalary,
+		"card_badges":        p.CardBadges,
+		"card_logo":          p.CardLogo,
+		"description":        p.Description,
+		"description_markup": p.DescriptionMarkup,
+		"criteria_item":      p.CriteriaItem,
+		"criteria_header":    p.CriteriaHeader,
+		"criteria_value":     p.CriteriaValue,
+		"closed_posting":     p.ClosedPosting,
+		"top_card":           p.TopCard,
+		"applicant_count":    p.ApplicantCount,
+	}
+}
+
+// LayoutDriftError reports a page that loaded but no longer matches the selector profile, which
+// usually means LinkedIn changed its markup and the profile needs updating
+type LayoutDriftError struct {
+	Profile string   // Version of the selector profile
+	URL     string   // Page that did not match
+	Missing []string // Profile fields that matched nothing
+}
+
+func (e *LayoutDriftError) Error() string {
+	return fmt.Sprintf("layout drift on %s: selector profile %s found no %s", e.URL, e.Profile, strings.Join(e.Missing, ", "))
+}
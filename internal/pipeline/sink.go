@@ -0,0 +1,68 @@
+package pipeline
+
+import (
+	"time"
+
+	"github.com/jobs-scraper/internal/models"
+)
+
+const (
+	defaultBatchSize     = 100
+	defaultFlushInterval = 10 * time.Second
+)
+
+// BatchStore persists a batch of jobs with their descriptions, returning the jobs it saved
+type BatchStore interface {
+	SaveBatch(batch []models.JobWithDescription) ([]models.Job, error)
+}
+
+// BatchReport is the outcome of writing one batch
+type BatchReport struct {
+	Number int          // 1-based batch number within the run
+	Size   int          // Number of jobs in the batch
+	Saved  []models.Job // Jobs written to the database
+	Err    error        // Why the batch, or some of its rows, failed
+}
+
+// SinkJobs writes jobs to store every batchSize items or every flushInterval, whichever comes
+// first, and flushes whatever is left once jobChan is closed
+func SinkJobs(jobChan <-chan models.JobWithDescription, store BatchStore, batchSize int, flushInterval time.Duration) <-chan BatchReport {
+	reportChan := make(chan BatchReport, 10)
+
+	go func() {
+		defer close(reportChan)
+
+		batch := make([]models.JobWithDescription, 0, batchSize)
+		number := 0
+		ticker := time.NewTicker(flushInterval)
+		defer ticker.Stop()
+
+		flush := func() {
+			if len(batch) == 0 {
+				return
+			}
+			number++
+			saved, err := store.SaveBatch(batch)
+			reportChan <- BatchReport{Number: number, Size: len(batch), Saved: saved, Err: err}
+			batch = make([]models.JobWithDescription, 0, batchSize)
+		}
+
+		for {
+			select {
+			case job, ok := <-jobChan:
+				if !ok {
+					flush()
+					return
+				}
+				batch = append(batch, job)
+				if len(batch) >= batchSize {
+					flush()
+				}
+			case <-ticker.C:
+				flush()
+			}
+		}
+	}()
+
+	return reportChan
+}
@@ -0,0 +1,305 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/jobs-scraper/internal/models"
+	"github.com/jobs-scraper/internal/utils"
+)
+
+// JobSource is a job board the pipeline can pull postings from
+type JobSource interface {
+	// Name identifies the source and is stored in the jobs.source column
+	Name() string
+
+	// ListJobs streams the postings matching params to jobChan, fetching at most numPages pages
+	ListJobs(ctx context.Context, numPages int, params models.SearchQuery, jobChan chan<- models.Job) error
+
+	// FetchJobDescription fetches the details of a single posting listed by this source
+	FetchJobDescription(ctx context.Context, job models.Job) (models.JobDescription, error)
+}
+
+// ResumableJobSource is a source whose listing can continue from a given page and which can
+// fetch the description of any job it listed, even in an earlier process. Its jobs are
+// checkpointed as pending until their descriptions are saved.
+type ResumableJobSource interface {
+	JobSource
+
+	// ListJobsFrom lists pages startPage to numPages-1, or for sources that list whole boards,
+	// boards startPage onwards. pageDone is called with the jobs of each page before they are
+	// sent to jobChan, a pageDone error stops the listing.
+	ListJobsFrom(ctx context.Context, startPage, numPages int, params models.SearchQuery, jobChan chan<- models.Job, pageDone func(page int, jobs []models.Job) error) error
+}
+
+// RecheckableSource is a source that can fetch a stored posting again to find out whether it closed
+type RecheckableSource interface {
+	JobSource
+
+	// RecheckJob fetches the posting of job from the source, never from a fresh cached copy. A
+	// posting that no longer accepts applications is returned with Closed set.
+	RecheckJob(ctx context.Context, job models.Job) (models.JobDescription, error)
+}
+
+// RateLimitedSource is a source whose request rate the pipeline can set
+type RateLimitedSource interface {
+	// SetRequestInterval sets the minimum time between two requests to the same host
+	SetRequestInterval(interval time.Duration)
+}
+
+// ProxiedSource is a source whose requests can be sent through a proxy pool
+type ProxiedSource interface {
+	SetProxyPool(pool *utils.ProxyPool)
+}
+
+// RecordableSource is a source whose requests can be recorded to or replayed from a cassette
+type RecordableSource interface {
+	UseCassette(cassette *utils.Cassette)
+}
+
+// CachedSource is a source whose responses can be served from a response cache
+type CachedSource interface {
+	UseResponseCache(cache *utils.ResponseCache)
+}
+
+// RefreshingSource is a source whose cached pages must not outlive the refresh policy, so that a
+// description fetched again as a refresh comes from the source
+type RefreshingSource interface {
+	SetRefreshPolicy(policy RefreshPolicy)
+}
+
+// DefaultCacheRoutes are how long the pages of each source stay fresh in the response cache.
+// Job pages rarely change, search results and board listings do.
+var DefaultCacheRoutes = []utils.CacheRoute{
+	utils.NewCacheRoute(`linkedin\.com/jobs/view/`, 7*24*time.Hour),
+	utils.NewCacheRoute(`linkedin\.com/jobs-guest/jobs/api/`, 1*time.Hour),
+	utils.NewCacheRoute(`greenhouse\.io/`, 1*time.Hour),
+	utils.NewCacheRoute(`lever\.co/`, 1*time.Hour),
+	utils.NewCacheRoute(`ashbyhq\.com/`, 1*time.Hour),
+}
+
+// BreakerSource is a source whose requests go through a circuit breaker
+type BreakerSource interface {
+	CircuitBreaker() *utils.CircuitBreaker
+}
+
+// sourceClient is the HTTP client a source shares between all its workers. Requests are retried,
+// rate limited per host and paused when a host starts blocking us.
+type sourceClient struct {
+	limited *utils.RateLimitedClient
+	request *utils.RetryableHTTPRequestImpl
+	breaker *utils.CircuitBreaker
+}
+
+func newSourceClient(retryConfig utils.RetryConfig, timeout time.Duration, limits utils.HostLimits, breakerConfig utils.BreakerConfig) *sourceClient {
+	limited := utils.NewRateLimitedClient(timeout, limits)
+	request := utils.NewRetryableHTTPRequestWithClient(limited, retryConfig)
+	breaker := utils.NewCircuitBreaker(breakerConfig)
+	request.SetCircuitBreaker(breaker)
+
+	return &sourceClient{
+		limited: limited,
+		request: request,
+		breaker: breaker,
+	}
+}
+
+func (c *sourceClient) CircuitBreaker() *utils.CircuitBreaker {
+	return c.breaker
+}
+
+func (c *sourceClient) SetRequestInterval(interval time.Duration) {
+	c.limited.SetInterval(interval)
+}
+
+func (c *sourceClient) SetProxyPool(pool *utils.ProxyPool) {
+	c.request.SetProxyPool(pool)
+}
+
+func (c *sourceClient) UseResponseCache(cache *utils.ResponseCache) {
+	c.request.UseResponseCache(cache)
+}
+
+func (c *sourceClient) UseCassette(cassette *utils.Cassette) {
+	c.request.UseCassette(cassette)
+}
+
+// do sends request with retries, the caller closes the response body
+func (c *sourceClient) do(ctx context.Context, request *utils.Request) (*http.Response, error) {
+	return c.request.RetryableHTTPRequest(ctx, request)
+}
+
+// get fetches url with retries, the caller closes the response body
+func (c *sourceClient) get(ctx context.Context, url string) (*http.Response, error) {
+	return c.do(ctx, utils.NewRequest(http.MethodGet, url))
+}
+
+// getJSON fetches url with retries and decodes the JSON response body into v
+func (c *sourceClient) getJSON(ctx context.Context, url string, v any) error {
+	request := utils.NewRequest(http.MethodGet, url).WithHeader("Accept", "application/json")
+	return c.doDecoded(ctx, request, func(body io.Reader) error {
+		return json.NewDecoder(body).Decode(v)
+	})
+}
+
+// postJSON sends body as JSON to url with retries and decodes the JSON response body into v
+func (c *sourceClient) postJSON(ctx context.Context, url string, body, v any) error {
+	request, err := utils.NewRequest(http.MethodPost, url).WithHeader("Accept", "application/json").WithJSONBody(body)
+	if err != nil {
+		return err
+	}
+	return c.doDecoded(ctx, request, func(body io.Reader) error {
+		return json.NewDecoder(body).Decode(v)
+	})
+}
+
+// getXML fetches url with retries and decodes the XML response body into v
+func (c *sourceClient) getXML(ctx context.Context, url string, v any) error {
+	return c.doDecoded(ctx, utils.NewRequest(http.MethodGet, url), func(body io.Reader) error {
+		return xml.NewDecoder(body).Decode(v)
+	})
+}
+
+func (c *sourceClient) doDecoded(ctx context.Context, request *utils.Request, decode func(io.Reader) error) error {
+	res, err := c.do(ctx, request)
+	if err != nil {
+		return fmt.Errorf("error fetching %s: %w", request.URL, err)
+	}
+	defer res.Body.Close()
+
+	if err := decode(res.Body); err != nil {
+		return fmt.Errorf("error decoding response from %s: %w", request.URL, err)
+	}
+
+	return nil
+}
+
+// matchesKeywords reports whether every word of keywords appears in title, ignoring case
+func matchesKeywords(title, keywords string) bool {
+	title = strings.ToLower(title)
+	for _, word := range strings.Fields(strings.ToLower(keywords)) {
+		if !strings.Contains(title, word) {
+			return false
+		}
+	}
+	return true
+}
+
+// parseHTMLFragment formats an HTML fragment returned by a JSON API the same way as LinkedIn descriptions
+func parseHTMLFragment(fragment string) (formattedDescription, error) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(fragment))
+	if err != nil {
+		return formattedDescription{}, err
+	}
+
+	return formatDescription(doc.Find("body")), nil
+}
+
+// syntheticJobID derives a stable positive job ID from sources that identify postings by strings
+func syntheticJobID(parts ...string) int64 {
+	h := fnv.New64a()
+	for _, part := range parts {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return int64(h.Sum64() & math.MaxInt64)
+}
+
+// jobTextLength is the length of the VARCHAR(255) title, company and location columns of jobs
+const jobTextLength = 255
+
+// truncate shortens s to at most n runes so it fits VARCHAR(n) columns
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
+
+// descriptionCache keeps descriptions returned by listing APIs until the workers ask for them
+type descriptionCache struct {
+	mu           sync.Mutex
+	descriptions map[int64]models.JobDescription
+}
+
+func newDescriptionCache() *descriptionCache {
+	return &descriptionCache{descriptions: make(map[int64]models.JobDescription)}
+}
+
+func (c *descriptionCache) put(jd models.JobDescription) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.descriptions[jd.JobID] = jd
+}
+
+// has reports whether job was listed by this process
+func (c *descriptionCache) has(job models.Job) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, ok := c.descriptions[job.ID]
+	return ok
+}
+
+func (c *descriptionCache) get(job models.Job) (models.JobDescription, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	jd, ok := c.descriptions[job.ID]
+	if !ok {
+		return models.JobDescription{}, fmt.Errorf("%s job %d was not listed by this source", job.Source, job.ID)
+	}
+	if jd.Description == "" {
+		return models.JobDescription{}, fmt.Errorf("job description not found")
+	}
+	return jd, nil
+}
+
+// apiSourceLimits bound the requests of the job board API sources to each host
+var apiSourceLimits = utils.HostLimits{MaxConcurrent: 4}
+
+// apiSourceTimeout is the timeout of a single job board API request
+const apiSourceTimeout = 30 * time.Second
+
+// withRetryDefaults fills unset retry settings with the scraper defaults
+func withRetryDefaults(config utils.RetryConfig) utils.RetryConfig {
+	if config.MaxRetries == 0 {
+		config.MaxRetries = 3
+	}
+	if config.BaseDelay == 0 {
+		config.BaseDelay = 1 * time.Second
+	}
+	if config.MaxDelay == 0 {
+		config.MaxDelay = 30 * time.Second
+	}
+	return config
+}
+
+// formatSalary renders the salary fields of a job for the criteria map, e.g. "100000-150000 USD per year"
+func formatSalary(job models.Job) string {
+	if job.SalaryMin == 0 && job.SalaryMax == 0 {
+		return ""
+	}
+
+	salary := fmt.Sprintf("%.0f", job.SalaryMin)
+	if job.SalaryMax != 0 && job.SalaryMax != job.SalaryMin {
+		salary = fmt.Sprintf("%.0f-%.0f", job.SalaryMin, job.SalaryMax)
+	}
+	if job.SalaryCurrency != "" {
+		salary += " " + job.SalaryCurrency
+	}
+	if job.SalaryPeriod != "" {
+		salary += " per " + job.SalaryPeriod
+	}
+	return salary
+}
@@ -0,0 +1,131 @@
+package repo
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/jobs-scraper/internal/models"
+)
+
+// maxQueryParams is the number of bind parameters Postgres accepts in one statement
+const maxQueryParams = 65535
+
+// execer is implemented by both *sql.DB and *sql.Tx
+type execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+// chunks splits rows so that no multi-row INSERT exceeds the Postgres parameter limit
+func chunks[T any](rows []T, columnsPerRow int) [][]T {
+	size := maxQueryParams / columnsPerRow
+
+	result := make([][]T, 0, len(rows)/size+1)
+	for len(rows) > size {
+		result = append(result, rows[:size])
+		rows = rows[size:]
+	}
+	return append(result, rows)
+}
+
+// RowError reports a job of a batch that could not be saved
+type RowError struct {
+	Source string
+	JobID  int64
+	Err    error
+}
+
+func (e RowError) Error() string {
+	return fmt.Sprintf("%s job %d: %v", e.Source, e.JobID, e.Err)
+}
+
+// BatchError lists the rows of a batch that failed while the others were saved
+type BatchError struct {
+	Rows []RowError
+}
+
+func (e *BatchError) Error() string {
+	rows := make([]string, 0, len(e.Rows))
+	for _, row := range e.Rows {
+		rows = append(rows, row.Error())
+	}
+	return fmt.Sprintf("%d rows failed: %s", len(e.Rows), strings.Join(rows, "; "))
+}
+
+// BatchRepository writes jobs together with their descriptions
+type BatchRepository struct {
+	db *sql.DB
+}
+
+func NewBatchRepository(db *sql.DB) *BatchRepository {
+	return &BatchRepository{db: db}
+}
+
+// SaveBatch writes the jobs and descriptions of a batch in one transaction. When the transaction
+// fails every row is retried on its own, the saved jobs are returned and the failed rows are
+// reported in a *BatchError.
+func (r *BatchRepository) SaveBatch(batch []models.JobWithDescription) ([]models.Job, error) {
+	if len(batch) == 0 {
+		return nil, nil
+	}
+
+	if err := r.saveInTx(batch); err == nil {
+		saved := make([]models.Job, 0, len(batch))
+		for _, item := range batch {
+			saved = append(saved, item.Job)
+		}
+		return saved, nil
+	}
+
+	// Find out which rows broke the batch
+	saved := make([]models.Job, 0, len(batch))
+	batchErr := &BatchError{}
+	for _, item := range batch {
+		if err := r.saveInTx([]models.JobWithDescription{item}); err != nil {
+			batchErr.Rows = append(batchErr.Rows, RowError{Source: item.Job.Source, JobID: item.Job.ID, Err: err})
+			continue
+		}
+		saved = append(saved, item.Job)
+	}
+
+	if len(batchErr.Rows) == 0 {
+		return saved, nil
+	}
+	return saved, batchErr
+}
+
+// splitBatch returns the jobs and the descriptions to upsert. A closed posting whose page no
+// longer shows the description only updates its job, keeping the stored description.
+func splitBatch(batch []models.JobWithDescription) ([]models.Job, []models.JobDescription) {
+	jobs := make([]models.Job, 0, len(batch))
+	descriptions := make([]models.JobDescription, 0, len(batch))
+	for _, item := range batch {
+		jobs = append(jobs, item.Job)
+		if item.JobDescription.Description != "" {
+			descriptions = append(descriptions, item.JobDescription)
+		}
+	}
+	return jobs, descriptions
+}
+
+func (r *BatchRepository) saveInTx(batch []models.JobWithDescription) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	jobs, descriptions := splitBatch(batch)
+
+	if err := saveJobs(tx, jobs); err != nil {
+		return err
+	}
+	if err := saveJobDescriptions(tx, descriptions); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing batch: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package repo
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/jobs-scraper/internal/models"
+)
+
+func TestSplitBatchKeepsStoredDescriptionOfClosedPosting(t *testing.T) {
+	closedAt := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	batch := []models.JobWithDescription{
+		{
+			Job:            models.Job{ID: 1, Source: models.SourceLinkedIn},
+			JobDescription: models.JobDescription{JobID: 1, Source: models.SourceLinkedIn, Description: "Write Go services."},
+		},
+		{
+			Job:            models.Job{ID: 2, Source: models.SourceLinkedIn, ClosedAt: &closedAt},
+			JobDescription: models.JobDescription{JobID: 2, Source: models.SourceLinkedIn, Closed: true},
+		},
+		{
+			Job:            models.Job{ID: 3, Source: models.SourceLinkedIn, ClosedAt: &closedAt},
+			JobDescription: models.JobDescription{JobID: 3, Source: models.SourceLinkedIn, Description: "Still shown.", Closed: true},
+		},
+	}
+
+	jobs, descriptions := splitBatch(batch)
+
+	if len(jobs) != 3 {
+		t.Fatalf("upserting %d jobs, want 3", len(jobs))
+	}
+	if jobs[1].ClosedAt == nil {
+		t.Error("closed job 2 is upserted without closed_at")
+	}
+
+	var ids []int64
+	for _, jd := range descriptions {
+		ids = append(ids, jd.JobID)
+	}
+	if !slices.Equal(ids, []int64{1, 3}) {
+		t.Errorf("upserting descriptions of jobs %v, want [1 3]", ids)
+	}
+}
@@ -5,8 +5,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/jobs-scraper/internal/models"
+	"github.com/lib/pq"
 )
 
 type JobDescriptionRepository struct {
@@ -23,14 +25,31 @@ func NewJobDescriptionRepository(db *sql.DB) *JobDescriptionRepository {
 	return &JobDescriptionRepository{db: db}
 }
 
+// jobDescriptionColumnCount is the number of values saveJobDescriptions writes per description
+const jobDescriptionColumnCount = 13
+
 func (r *JobDescriptionRepository) SaveJobDescriptions(jobDescriptions []models.JobDescription) error {
+	return saveJobDescriptions(r.db, jobDescriptions)
+}
+
+// saveJobDescriptions upserts descriptions in as few statements as the parameter limit allows
+func saveJobDescriptions(ex execer, jobDescriptions []models.JobDescription) error {
+	for _, chunk := range chunks(jobDescriptions, jobDescriptionColumnCount) {
+		if err := saveJobDescriptionChunk(ex, chunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func saveJobDescriptionChunk(ex execer, jobDescriptions []models.JobDescription) error {
 	if len(jobDescriptions) == 0 {
 		return nil
 	}
 
 	// Build the VALUES clause dynamically
 	valueStrings := make([]string, 0, len(jobDescriptions))
-	valueArgs := make([]interface{}, 0, len(jobDescriptions)*3)
+	valueArgs := make([]interface{}, 0, len(jobDescriptions)*jobDescriptionColumnCount)
 
 	for i, jd := range jobDescriptions {
 		// Convert criteria map to JSONB
@@ -38,21 +57,46 @@ func (r *JobDescriptionRepository) SaveJobDescriptions(jobDescriptions []models.
 		if err != nil {
 			return fmt.Errorf("error marshaling job criteria for job %d: %v", jd.JobID, err)
 		}
+		languages := jd.LanguageRequirements
+		if languages == nil {
+			languages = []models.LanguageRequirement{}
+		}
+		languagesByte, err := json.Marshal(languages)
+		if err != nil {
+			return fmt.Errorf("error marshaling language requirements for job %d: %v", jd.JobID, err)
+		}
 
-		valueStrings = append(valueStrings, fmt.Sprintf("($%d, $%d, $%d)", i*3+1, i*3+2, i*3+3))
-		valueArgs = append(valueArgs, jd.JobID, jd.Description, criteriaByte)
+		n := i * jobDescriptionColumnCount
+		valueStrings = append(valueStrings, fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d)",
+			n+1, n+2, n+3, n+4, n+5, n+6, n+7, n+8, n+9, n+10, n+11, n+12, n+13))
+		valueArgs = append(valueArgs, jd.JobID, jd.Source, jd.Description, criteriaByte,
+			nullString(jd.DescriptionMarkdown), nullString(jd.DescriptionHTML),
+			nullString(jd.Seniority), nullString(jd.EmploymentType),
+			pq.Array(nonNilStrings(jd.JobFunctions)), pq.Array(nonNilStrings(jd.Industries)),
+			nullInt(jd.ApplicantCount), nullString(jd.Language), languagesByte)
 	}
 
 	sqlStatement := fmt.Sprintf(`
-		INSERT INTO job_descriptions (job_id, description, job_criteria)
+		INSERT INTO job_descriptions (job_id, source, description, job_criteria,
+			description_markdown, description_html, seniority, employment_type, job_functions, industries, applicant_count,
+			description_language, language_requirements)
 		VALUES %s
-		ON CONFLICT (job_id) DO UPDATE SET
+		ON CONFLICT (source, job_id) DO UPDATE SET
 		description = EXCLUDED.description,
 		job_criteria = EXCLUDED.job_criteria,
+		description_markdown = EXCLUDED.description_markdown,
+		description_html = EXCLUDED.description_html,
+		seniority = EXCLUDED.seniority,
+		employment_type = EXCLUDED.employment_type,
+		job_functions = EXCLUDED.job_functions,
+		industries = EXCLUDED.industries,
+		applicant_count = EXCLUDED.applicant_count,
+		description_language = EXCLUDED.description_language,
+		language_requirements = EXCLUDED.language_requirements,
 		updated_at = CURRENT_TIMESTAMP
 	`, strings.Join(valueStrings, ","))
 
-	_, err := r.db.Exec(sqlStatement, valueArgs...)
+	_, err := ex.Exec(sqlStatement, valueArgs...)
 	if err != nil {
 		return fmt.Errorf("error saving job descriptions: %v", err)
 	}
@@ -60,15 +104,59 @@ func (r *JobDescriptionRepository) SaveJobDescriptions(jobDescriptions []models.
 	return nil
 }
 
-func (r *JobDescriptionRepository) GetJobDescriptionByJobID(jobID int64) (string, map[string]string, error) {
+// FreshJobs returns the jobs among the given ones whose description was saved at or after since
+func (r *JobDescriptionRepository) FreshJobs(jobs []models.Job, since time.Time) ([]models.Job, error) {
+	if len(jobs) == 0 {
+		return nil, nil
+	}
+
+	sources, ids := jobKeyArrays(jobs)
+
+	sqlStatement := `
+		SELECT jd.source, jd.job_id
+		FROM job_descriptions jd
+		JOIN unnest($1::text[], $2::bigint[]) AS listed(source, job_id)
+			ON jd.source = listed.source AND jd.job_id = listed.job_id
+		WHERE jd.updated_at >= $3
+	`
+	rows, err := r.db.Query(sqlStatement, pq.Array(sources), pq.Array(ids), since)
+	if err != nil {
+		return nil, fmt.Errorf("error querying stored job descriptions: %v", err)
+	}
+	defer rows.Close()
+
+	stored := make(map[jobKey]struct{})
+	for rows.Next() {
+		var key jobKey
+		if err := rows.Scan(&key.source, &key.id); err != nil {
+			return nil, fmt.Errorf("error scanning stored job description row: %v", err)
+		}
+		stored[key] = struct{}{}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over stored job description rows: %v", err)
+	}
+
+	fresh := make([]models.Job, 0, len(stored))
+	for _, job := range jobs {
+		if _, ok := stored[jobKey{source: job.Source, id: job.ID}]; ok {
+			fresh = append(fresh, job)
+		}
+	}
+
+	return fresh, nil
+}
+
+func (r *JobDescriptionRepository) GetJobDescriptionByJobID(source string, jobID int64) (string, map[string]string, error) {
 	var (
 		description  string
 		criteriaByte []byte
 		criteria     map[string]string
 	)
 
-	sqlStatement := `SELECT description, job_criteria FROM job_descriptions WHERE job_id = $1`
-	err := r.db.QueryRow(sqlStatement, jobID).Scan(&description, &criteriaByte)
+	sqlStatement := `SELECT description, job_criteria FROM job_descriptions WHERE source = $1 AND job_id = $2`
+	err := r.db.QueryRow(sqlStatement, source, jobID).Scan(&description, &criteriaByte)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return "", nil, nil // No description found
@@ -85,3 +173,30 @@ func (r *JobDescriptionRepository) GetJobDescriptionByJobID(jobID int64) (string
 
 	return description, criteria, nil
 }
+
+// GetLanguageRequirements returns the language a job description is written in and the spoken
+// languages it asks for. requirements is nil for descriptions saved before languages were detected.
+func (r *JobDescriptionRepository) GetLanguageRequirements(source string, jobID int64) (string, []models.LanguageRequirement, error) {
+	var (
+		language      sql.NullString
+		languagesByte []byte
+		requirements  []models.LanguageRequirement
+	)
+
+	sqlStatement := `SELECT description_language, language_requirements FROM job_descriptions WHERE source = $1 AND job_id = $2`
+	err := r.db.QueryRow(sqlStatement, source, jobID).Scan(&language, &languagesByte)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", nil, nil
+		}
+		return "", nil, fmt.Errorf("error fetching language requirements: %v", err)
+	}
+
+	if languagesByte != nil {
+		if err := json.Unmarshal(languagesByte, &requirements); err != nil {
+			return "", nil, fmt.Errorf("error unmarshaling language requirements: %v", err)
+		}
+	}
+
+	return language.String, requirements, nil
+}
@@ -3,27 +3,51 @@ package repo
 import (
 	"database/sql"
 	"fmt"
+	"strings"
 
 	"github.com/jobs-scraper/internal/models"
+	"github.com/lib/pq"
 )
 
 type JobRepository struct {
 	db *sql.DB
 }
 
+// jobKey identifies a job across all sources
+type jobKey struct {
+	source string
+	id     int64
+}
+
+// jobColumns lists the columns read by scanJob, in order
+const jobColumns = `id, source, title, company, company_link, location, job_link,
+	workplace_type, remote, salary_min, salary_max, salary_currency, salary_period,
+	posted_at, salary_insight, badges, company_logo, urn, closed_at,
+	salary_annual_min, salary_annual_max, salary_annual_currency,
+	work_type_filter, country_code, region, city`
+
+// jobColumnCount is the number of values SaveJobs writes per job
+const jobColumnCount = 26
+
+// rowScanner is implemented by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
 func NewJobRepository(db *sql.DB) *JobRepository {
 	return &JobRepository{db: db}
 }
 
 func (r *JobRepository) SaveJobs(jobs []models.Job) error {
-	if len(jobs) == 0 {
-		return nil
-	}
+	return saveJobs(r.db, jobs)
+}
 
-	// Deduplicate jobs by ID to avoid duplicate errors
-	jobMap := make(map[int64]models.Job)
+// saveJobs upserts jobs in as few statements as the parameter limit allows
+func saveJobs(ex execer, jobs []models.Job) error {
+	// Deduplicate jobs by source and ID to avoid duplicate errors
+	jobMap := make(map[jobKey]models.Job)
 	for _, job := range jobs {
-		jobMap[job.ID] = job
+		jobMap[jobKey{source: job.Source, id: job.ID}] = job
 	}
 
 	uniqueJobs := make([]models.Job, 0, len(jobMap))
@@ -31,8 +55,25 @@ func (r *JobRepository) SaveJobs(jobs []models.Job) error {
 		uniqueJobs = append(uniqueJobs, job)
 	}
 
+	for _, chunk := range chunks(uniqueJobs, jobColumnCount) {
+		if err := saveJobChunk(ex, chunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func saveJobChunk(ex execer, uniqueJobs []models.Job) error {
+	if len(uniqueJobs) == 0 {
+		return nil
+	}
+
 	sqlStatement := `
-        INSERT INTO jobs (id, title, company, company_link, location, job_link)
+        INSERT INTO jobs (id, source, title, company, company_link, location, job_link,
+            workplace_type, remote, salary_min, salary_max, salary_currency, salary_period,
+            posted_at, salary_insight, badges, company_logo, urn, closed_at,
+            salary_annual_min, salary_annual_max, salary_annual_currency,
+            work_type_filter, country_code, region, city)
         VALUES 
     `
 
@@ -40,26 +81,56 @@ func (r *JobRepository) SaveJobs(jobs []models.Job) error {
 	vals := []interface{}{}
 	for i, job := range uniqueJobs {
 
-		n := i * 6
+		n := i * jobColumnCount
 
 		if i > 0 {
 			sqlStatement += ","
 		}
-		sqlStatement += fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d)", n+1, n+2, n+3, n+4, n+5, n+6)
+		placeholders := make([]string, jobColumnCount)
+		for j := range placeholders {
+			placeholders[j] = fmt.Sprintf("$%d", n+j+1)
+		}
+		sqlStatement += "(" + strings.Join(placeholders, ", ") + ")"
 
-		vals = append(vals, job.ID, job.Title, job.Company, job.CompanyLink, job.Location, job.JobLink)
+		vals = append(vals, job.ID, job.Source, job.Title, job.Company, job.CompanyLink, job.Location, job.JobLink,
+			nullString(job.WorkplaceType), job.Remote, nullFloat(job.SalaryMin), nullFloat(job.SalaryMax),
+			nullString(job.SalaryCurrency), nullString(job.SalaryPeriod),
+			job.PostedAt, nullString(job.SalaryInsight), pq.Array(nonNilStrings(job.Badges)), nullString(job.CompanyLogo), nullString(job.URN),
+			job.ClosedAt, nullFloat(job.SalaryAnnualMin), nullFloat(job.SalaryAnnualMax), nullString(job.SalaryAnnualCurrency),
+			nullString(job.WorkTypeFilter), nullString(job.CountryCode), nullString(job.Region), nullString(job.City))
 	}
 
 	sqlStatement += `
-        ON CONFLICT (id) DO UPDATE SET
+        ON CONFLICT (source, id) DO UPDATE SET
         title = EXCLUDED.title,
         company = EXCLUDED.company,
         company_link = EXCLUDED.company_link,
         location = EXCLUDED.location,
-        job_link = EXCLUDED.job_link
+        job_link = EXCLUDED.job_link,
+        workplace_type = EXCLUDED.workplace_type,
+        remote = EXCLUDED.remote,
+        work_type_filter = COALESCE(EXCLUDED.work_type_filter, jobs.work_type_filter),
+        country_code = EXCLUDED.country_code,
+        region = EXCLUDED.region,
+        city = EXCLUDED.city,
+        salary_min = EXCLUDED.salary_min,
+        salary_max = EXCLUDED.salary_max,
+        salary_currency = EXCLUDED.salary_currency,
+        salary_period = EXCLUDED.salary_period,
+        salary_annual_min = EXCLUDED.salary_annual_min,
+        salary_annual_max = EXCLUDED.salary_annual_max,
+        salary_annual_currency = EXCLUDED.salary_annual_currency,
+        posted_at = COALESCE(EXCLUDED.posted_at, jobs.posted_at),
+        salary_insight = EXCLUDED.salary_insight,
+        badges = EXCLUDED.badges,
+        company_logo = EXCLUDED.company_logo,
+        urn = EXCLUDED.urn,
+        closed_at = CASE WHEN EXCLUDED.closed_at IS NULL THEN NULL ELSE COALESCE(jobs.closed_at, EXCLUDED.closed_at) END,
+        checked_at = CURRENT_TIMESTAMP,
+        last_seen_at = CURRENT_TIMESTAMP
     `
 
-	_, err := r.db.Exec(sqlStatement, vals...)
+	_, err := ex.Exec(sqlStatement, vals...)
 	if err != nil {
 		return fmt.Errorf("error inserting jobs: %v", err)
 	}
@@ -67,8 +138,87 @@ func (r *JobRepository) SaveJobs(jobs []models.Job) error {
 	return nil
 }
 
+// TouchJobs marks stored jobs as listed just now
+func (r *JobRepository) TouchJobs(jobs []models.Job) error {
+	if len(jobs) == 0 {
+		return nil
+	}
+
+	sources, ids := jobKeyArrays(jobs)
+
+	sqlStatement := `
+		UPDATE jobs j SET last_seen_at = CURRENT_TIMESTAMP
+		FROM unnest($1::text[], $2::bigint[]) AS listed(source, id)
+		WHERE j.source = listed.source AND j.id = listed.id
+	`
+	if _, err := r.db.Exec(sqlStatement, pq.Array(sources), pq.Array(ids)); err != nil {
+		return fmt.Errorf("error updating last seen time of jobs: %v", err)
+	}
+
+	return nil
+}
+
+// MarkChecked records that the postings of stored jobs were found open just now
+func (r *JobRepository) MarkChecked(jobs []models.Job) error {
+	if len(jobs) == 0 {
+		return nil
+	}
+
+	sources, ids := jobKeyArrays(jobs)
+
+	sqlStatement := `
+		UPDATE jobs j SET checked_at = CURRENT_TIMESTAMP, closed_at = NULL
+		FROM unnest($1::text[], $2::bigint[]) AS checked(source, id)
+		WHERE j.source = checked.source AND j.id = checked.id
+	`
+	if _, err := r.db.Exec(sqlStatement, pq.Array(sources), pq.Array(ids)); err != nil {
+		return fmt.Errorf("error updating check time of jobs: %v", err)
+	}
+
+	return nil
+}
+
+// MarkClosed records that the postings of stored jobs no longer accept applications, keeping the
+// time they were first found closed
+func (r *JobRepository) MarkClosed(jobs []models.Job) error {
+	if len(jobs) == 0 {
+		return nil
+	}
+
+	sources, ids := jobKeyArrays(jobs)
+
+	sqlStatement := `
+		UPDATE jobs j SET checked_at = CURRENT_TIMESTAMP, closed_at = COALESCE(j.closed_at, CURRENT_TIMESTAMP)
+		FROM unnest($1::text[], $2::bigint[]) AS checked(source, id)
+		WHERE j.source = checked.source AND j.id = checked.id
+	`
+	if _, err := r.db.Exec(sqlStatement, pq.Array(sources), pq.Array(ids)); err != nil {
+		return fmt.Errorf("error marking jobs as closed: %v", err)
+	}
+
+	return nil
+}
+
+// GetAllJobs returns the jobs whose postings are still open
 func (r *JobRepository) GetAllJobs() ([]models.Job, error) {
-	rows, err := r.db.Query("SELECT id, title, company, company_link, location, job_link FROM jobs")
+	return r.queryJobs("SELECT " + jobColumns + " FROM jobs WHERE closed_at IS NULL")
+}
+
+// GetOpenJobs returns up to limit open jobs of the given sources, the ones checked least
+// recently first
+func (r *JobRepository) GetOpenJobs(sources []string, limit int) ([]models.Job, error) {
+	sqlStatement := `
+		SELECT ` + jobColumns + `
+		FROM jobs
+		WHERE closed_at IS NULL AND source = ANY($1)
+		ORDER BY checked_at ASC NULLS FIRST, last_seen_at ASC
+		LIMIT $2
+	`
+	return r.queryJobs(sqlStatement, pq.Array(sources), limit)
+}
+
+func (r *JobRepository) queryJobs(query string, args ...any) ([]models.Job, error) {
+	rows, err := r.db.Query(query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("error querying jobs: %v", err)
 	}
@@ -76,8 +226,8 @@ func (r *JobRepository) GetAllJobs() ([]models.Job, error) {
 
 	var jobs []models.Job
 	for rows.Next() {
-		var job models.Job
-		if err := rows.Scan(&job.ID, &job.Title, &job.Company, &job.CompanyLink, &job.Location, &job.JobLink); err != nil {
+		job, err := scanJob(rows)
+		if err != nil {
 			return nil, fmt.Errorf("error scanning job row: %v", err)
 		}
 		jobs = append(jobs, job)
@@ -89,26 +239,17 @@ func (r *JobRepository) GetAllJobs() ([]models.Job, error) {
 
 	return jobs, nil
 }
-func (r *JobRepository) GetJobByID(id int) (*models.Job, error) {
-	var job models.Job
-
+func (r *JobRepository) GetJobByID(source string, id int64) (*models.Job, error) {
 	sqlStatement := `
-		SELECT id, title, company, company_link, location, job_link 
+		SELECT ` + jobColumns + `
 		FROM jobs 
-		WHERE id = $1
+		WHERE source = $1 AND id = $2
 	`
 
-	err := r.db.QueryRow(sqlStatement, id).Scan(
-		&job.ID,
-		&job.Title,
-		&job.Company,
-		&job.CompanyLink,
-		&job.Location,
-		&job.JobLink,
-	)
+	job, err := scanJob(r.db.QueryRow(sqlStatement, source, id))
 
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("job with ID %d not found", id)
+		return nil, fmt.Errorf("%s job with ID %d not found", source, id)
 	}
 
 	if err != nil {
@@ -117,3 +258,113 @@ func (r *JobRepository) GetJobByID(id int) (*models.Job, error) {
 
 	return &job, nil
 }
+
+// scanJob reads a row selected with jobColumns
+func scanJob(row rowScanner) (models.Job, error) {
+	var (
+		job                                         models.Job
+		companyLink, location, jobLink              sql.NullString
+		workplaceType, salaryCurrency, salaryPeriod sql.NullString
+		salaryMin, salaryMax                        sql.NullFloat64
+		salaryAnnualMin, salaryAnnualMax            sql.NullFloat64
+		salaryAnnualCurrency                        sql.NullString
+		workTypeFilter, countryCode, region, city   sql.NullString
+		postedAt, closedAt                          sql.NullTime
+		salaryInsight, companyLogo, urn             sql.NullString
+	)
+
+	err := row.Scan(
+		&job.ID,
+		&job.Source,
+		&job.Title,
+		&job.Company,
+		&companyLink,
+		&location,
+		&jobLink,
+		&workplaceType,
+		&job.Remote,
+		&salaryMin,
+		&salaryMax,
+		&salaryCurrency,
+		&salaryPeriod,
+		&postedAt,
+		&salaryInsight,
+		pq.Array(&job.Badges),
+		&companyLogo,
+		&urn,
+		&closedAt,
+		&salaryAnnualMin,
+		&salaryAnnualMax,
+		&salaryAnnualCurrency,
+		&workTypeFilter,
+		&countryCode,
+		&region,
+		&city,
+	)
+	if err != nil {
+		return job, err
+	}
+
+	job.CompanyLink = companyLink.String
+	job.Location = location.String
+	job.JobLink = jobLink.String
+	job.WorkplaceType = workplaceType.String
+	job.WorkTypeFilter = workTypeFilter.String
+	job.CountryCode = countryCode.String
+	job.Region = region.String
+	job.City = city.String
+	job.SalaryMin = salaryMin.Float64
+	job.SalaryMax = salaryMax.Float64
+	job.SalaryCurrency = salaryCurrency.String
+	job.SalaryPeriod = salaryPeriod.String
+	job.SalaryAnnualMin = salaryAnnualMin.Float64
+	job.SalaryAnnualMax = salaryAnnualMax.Float64
+	job.SalaryAnnualCurrency = salaryAnnualCurrency.String
+	if postedAt.Valid {
+		posted := postedAt.Time.UTC()
+		job.PostedAt = &posted
+	}
+	job.SalaryInsight = salaryInsight.String
+	job.CompanyLogo = companyLogo.String
+	job.URN = urn.String
+	if closedAt.Valid {
+		closed := closedAt.Time.UTC()
+		job.ClosedAt = &closed
+	}
+
+	return job, nil
+}
+
+// jobKeyArrays splits jobs into parallel source and ID arrays for unnest
+func jobKeyArrays(jobs []models.Job) ([]string, []int64) {
+	sources := make([]string, 0, len(jobs))
+	ids := make([]int64, 0, len(jobs))
+	for _, job := range jobs {
+		sources = append(sources, job.Source)
+		ids = append(ids, job.ID)
+	}
+	return sources, ids
+}
+
+// nullString stores empty strings as NULL
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+// nonNilStrings keeps NOT NULL array columns from receiving NULL
+func nonNilStrings(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
+
+// nullInt stores zero values as NULL
+func nullInt(i int) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(i), Valid: i != 0}
+}
+
+// nullFloat stores zero values as NULL
+func nullFloat(f float64) sql.NullFloat64 {
+	return sql.NullFloat64{Float64: f, Valid: f != 0}
+}
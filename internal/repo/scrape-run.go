@@ -0,0 +1,245 @@
+package repo
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/jobs-scraper/internal/models"
+	"github.com/lib/pq"
+)
+
+type ScrapeRunRepository struct {
+	db *sql.DB
+}
+
+// PendingJob is a listed job whose description has not been saved yet
+type PendingJob struct {
+	Job      models.Job
+	QueryKey string // Key of the query that listed the job
+}
+
+// pendingJobColumnCount is the number of values savePendingJobs writes per job
+const pendingJobColumnCount = 5
+
+func NewScrapeRunRepository(db *sql.DB) *ScrapeRunRepository {
+	return &ScrapeRunRepository{db: db}
+}
+
+// CreateRun stores a new running scrape run for the profile
+func (r *ScrapeRunRepository) CreateRun(profile models.SearchProfile) (*models.ScrapeRun, error) {
+	profileByte, err := json.Marshal(profile)
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling search profile: %v", err)
+	}
+
+	run := &models.ScrapeRun{Status: models.RunStatusRunning, Profile: profile}
+
+	sqlStatement := `INSERT INTO scrape_runs (status, profile) VALUES ($1, $2) RETURNING id, started_at`
+	if err := r.db.QueryRow(sqlStatement, run.Status, profileByte).Scan(&run.ID, &run.StartedAt); err != nil {
+		return nil, fmt.Errorf("error creating scrape run: %v", err)
+	}
+
+	return run, nil
+}
+
+func (r *ScrapeRunRepository) GetRun(id int64) (*models.ScrapeRun, error) {
+	var (
+		run         models.ScrapeRun
+		profileByte []byte
+	)
+
+	sqlStatement := `SELECT id, status, profile, started_at FROM scrape_runs WHERE id = $1`
+	err := r.db.QueryRow(sqlStatement, id).Scan(&run.ID, &run.Status, &profileByte, &run.StartedAt)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("scrape run %d not found", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error querying scrape run: %v", err)
+	}
+
+	if err := json.Unmarshal(profileByte, &run.Profile); err != nil {
+		return nil, fmt.Errorf("error unmarshaling search profile of run %d: %v", id, err)
+	}
+
+	return &run, nil
+}
+
+// FinishRun records the final status of a run, errMsg is empty for runs that succeeded
+func (r *ScrapeRunRepository) FinishRun(id int64, status string, errMsg string) error {
+	sqlStatement := `
+		UPDATE scrape_runs
+		SET status = $2, error = NULLIF($3, ''), finished_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+	`
+
+	if _, err := r.db.Exec(sqlStatement, id, status, errMsg); err != nil {
+		return fmt.Errorf("error finishing scrape run %d: %v", id, err)
+	}
+
+	return nil
+}
+
+// MarkRunning flags a run being resumed as running again
+func (r *ScrapeRunRepository) MarkRunning(id int64) error {
+	sqlStatement := `UPDATE scrape_runs SET status = $2, error = NULL, finished_at = NULL WHERE id = $1`
+	if _, err := r.db.Exec(sqlStatement, id, models.RunStatusRunning); err != nil {
+		return fmt.Errorf("error resuming scrape run %d: %v", id, err)
+	}
+
+	return nil
+}
+
+// GetPageCheckpoints returns the number of pages done per source and query key
+func (r *ScrapeRunRepository) GetPageCheckpoints(runID int64) (map[string]map[string]int, error) {
+	rows, err := r.db.Query(`SELECT source, query_key, pages_done FROM scrape_run_pages WHERE run_id = $1`, runID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying page checkpoints: %v", err)
+	}
+	defer rows.Close()
+
+	checkpoints := make(map[string]map[string]int)
+	for rows.Next() {
+		var (
+			source, queryKey string
+			pagesDone        int
+		)
+		if err := rows.Scan(&source, &queryKey, &pagesDone); err != nil {
+			return nil, fmt.Errorf("error scanning page checkpoint row: %v", err)
+		}
+		if checkpoints[source] == nil {
+			checkpoints[source] = make(map[string]int)
+		}
+		checkpoints[source][queryKey] = pagesDone
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over page checkpoint rows: %v", err)
+	}
+
+	return checkpoints, nil
+}
+
+// SavePage records the jobs of a fetched page as pending and the page as done, in one transaction
+func (r *ScrapeRunRepository) SavePage(runID int64, source, queryKey string, pagesDone int, jobs []models.Job) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	for _, chunk := range chunks(jobs, pendingJobColumnCount) {
+		if err := savePendingJobs(tx, runID, queryKey, chunk); err != nil {
+			return err
+		}
+	}
+
+	sqlStatement := `
+		INSERT INTO scrape_run_pages (run_id, source, query_key, pages_done)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (run_id, source, query_key) DO UPDATE SET
+		pages_done = EXCLUDED.pages_done,
+		updated_at = CURRENT_TIMESTAMP
+	`
+	if _, err := tx.Exec(sqlStatement, runID, source, queryKey, pagesDone); err != nil {
+		return fmt.Errorf("error saving page checkpoint: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing page checkpoint: %v", err)
+	}
+	return nil
+}
+
+func savePendingJobs(ex execer, runID int64, queryKey string, jobs []models.Job) error {
+	if len(jobs) == 0 {
+		return nil
+	}
+
+	valueStrings := make([]string, 0, len(jobs))
+	valueArgs := make([]interface{}, 0, len(jobs)*pendingJobColumnCount)
+
+	for i, job := range jobs {
+		jobByte, err := json.Marshal(job)
+		if err != nil {
+			return fmt.Errorf("error marshaling pending job %d: %v", job.ID, err)
+		}
+
+		n := i * pendingJobColumnCount
+		valueStrings = append(valueStrings, fmt.Sprintf("($%d, $%d, $%d, $%d, $%d)", n+1, n+2, n+3, n+4, n+5))
+		valueArgs = append(valueArgs, runID, job.Source, job.ID, queryKey, jobByte)
+	}
+
+	sqlStatement := fmt.Sprintf(`
+		INSERT INTO scrape_run_pending_jobs (run_id, source, job_id, query_key, job)
+		VALUES %s
+		ON CONFLICT (run_id, source, job_id) DO NOTHING
+	`, strings.Join(valueStrings, ","))
+
+	if _, err := ex.Exec(sqlStatement, valueArgs...); err != nil {
+		return fmt.Errorf("error saving pending jobs: %v", err)
+	}
+
+	return nil
+}
+
+// GetPendingJobs returns the jobs of the run whose descriptions were not saved
+func (r *ScrapeRunRepository) GetPendingJobs(runID int64) ([]PendingJob, error) {
+	rows, err := r.db.Query(`SELECT query_key, job FROM scrape_run_pending_jobs WHERE run_id = $1`, runID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying pending jobs: %v", err)
+	}
+	defer rows.Close()
+
+	var pending []PendingJob
+	for rows.Next() {
+		var (
+			p       PendingJob
+			jobByte []byte
+		)
+		if err := rows.Scan(&p.QueryKey, &jobByte); err != nil {
+			return nil, fmt.Errorf("error scanning pending job row: %v", err)
+		}
+		if err := json.Unmarshal(jobByte, &p.Job); err != nil {
+			return nil, fmt.Errorf("error unmarshaling pending job: %v", err)
+		}
+		pending = append(pending, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over pending job rows: %v", err)
+	}
+
+	return pending, nil
+}
+
+// CountPendingJobs returns the number of jobs of the run whose descriptions were not saved
+func (r *ScrapeRunRepository) CountPendingJobs(runID int64) (int, error) {
+	var count int
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM scrape_run_pending_jobs WHERE run_id = $1`, runID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("error counting pending jobs: %v", err)
+	}
+
+	return count, nil
+}
+
+// RemovePendingJobs forgets pending jobs once their descriptions are saved
+func (r *ScrapeRunRepository) RemovePendingJobs(runID int64, jobs []models.Job) error {
+	if len(jobs) == 0 {
+		return nil
+	}
+
+	sources, ids := jobKeyArrays(jobs)
+
+	sqlStatement := `
+		DELETE FROM scrape_run_pending_jobs p
+		USING unnest($2::text[], $3::bigint[]) AS saved(source, job_id)
+		WHERE p.run_id = $1 AND p.source = saved.source AND p.job_id = saved.job_id
+	`
+	if _, err := r.db.Exec(sqlStatement, runID, pq.Array(sources), pq.Array(ids)); err != nil {
+		return fmt.Errorf("error removing pending jobs: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,77 @@
+package repo
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/jobs-scraper/internal/models"
+)
+
+type SearchQueryRepository struct {
+	db *sql.DB
+}
+
+func NewSearchQueryRepository(db *sql.DB) *SearchQueryRepository {
+	return &SearchQueryRepository{db: db}
+}
+
+// SaveSearchQuery stores the query with all of its filters if it is new and returns its ID
+func (r *SearchQueryRepository) SaveSearchQuery(query models.SearchQuery) (int64, error) {
+	var id int64
+
+	filtersByte, err := json.Marshal(query)
+	if err != nil {
+		return 0, fmt.Errorf("error marshaling search query filters: %v", err)
+	}
+
+	// Queries saved before the filters column existed get their filters on the next match
+	sqlStatement := `
+		INSERT INTO search_queries (query_key, keywords, location, f_wt, geo_id, filters)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (query_key) DO UPDATE SET filters = EXCLUDED.filters
+		RETURNING id
+	`
+
+	err = r.db.QueryRow(sqlStatement, query.Key(), query.Keywords, query.Location, query.FWT, query.GeoId, filtersByte).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("error saving search query: %v", err)
+	}
+
+	return id, nil
+}
+
+// SaveJobMatches records that query listed the given jobs
+func (r *SearchQueryRepository) SaveJobMatches(query models.SearchQuery, jobs []models.Job) error {
+	if len(jobs) == 0 {
+		return nil
+	}
+
+	queryID, err := r.SaveSearchQuery(query)
+	if err != nil {
+		return err
+	}
+
+	valueStrings := make([]string, 0, len(jobs))
+	valueArgs := make([]interface{}, 0, len(jobs)*2+1)
+	valueArgs = append(valueArgs, queryID)
+
+	for i, job := range jobs {
+		valueStrings = append(valueStrings, fmt.Sprintf("($%d, $%d, $1)", i*2+2, i*2+3))
+		valueArgs = append(valueArgs, job.Source, job.ID)
+	}
+
+	sqlStatement := fmt.Sprintf(`
+		INSERT INTO job_search_matches (source, job_id, search_query_id)
+		VALUES %s
+		ON CONFLICT (source, job_id, search_query_id) DO UPDATE SET
+		last_matched_at = CURRENT_TIMESTAMP
+	`, strings.Join(valueStrings, ","))
+
+	if _, err := r.db.Exec(sqlStatement, valueArgs...); err != nil {
+		return fmt.Errorf("error saving job search matches: %v", err)
+	}
+
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"strings"
 
 	"github.com/eduardolat/openroutergo"
 	"github.com/jobs-scraper/internal/models"
@@ -31,8 +32,9 @@ func (r *JobAnalysisResult) IsHighConfidence() bool {
 }
 
 type OpenRouterService struct {
-	model  string
-	apiKey string
+	model            string
+	apiKey           string
+	languagesChecked bool // The job was already checked against the candidate's languages
 }
 
 func NewOpenRouterService(model string, apiKey string) OpenRouterService {
@@ -42,6 +44,12 @@ func NewOpenRouterService(model string, apiKey string) OpenRouterService {
 	}
 }
 
+// SetLanguagesChecked leaves the language rule out of the prompt for jobs whose language
+// requirements the caller already checked
+func (s *OpenRouterService) SetLanguagesChecked(checked bool) {
+	s.languagesChecked = checked
+}
+
 func (s *OpenRouterService) AnalyzeJobDescription(cv string, jobDesc models.JobDescription) (*JobAnalysisResult, error) {
 	client, err := openroutergo.
 		NewClient().
@@ -51,15 +59,23 @@ func (s *OpenRouterService) AnalyzeJobDescription(cv string, jobDesc models.JobD
 		log.Fatalf("Failed to create client: %v", err)
 	}
 
-	// Build the user message with all the provided data
-	userMessage := fmt.Sprintf(`Analyze the following CV against the job description and criteria, then provide a recommendation following the schema below.
-		1) If there are missing skills, try to guess if they still match based on similar skills or experience in the cv.
-		for example: Javascript is mentioned in the cv, but the job requires Vanilla js, since they are the same thing, it should be included in the matching skills.
+	rules := []string{
+		`If there are missing skills, try to guess if they still match based on similar skills or experience in the cv.
+		for example: Javascript is mentioned in the cv, but the job requires Vanilla js, since they are the same thing, it should be included in the matching skills.`,
+	}
+	if !s.languagesChecked {
+		rules = append(rules, "The job shouldn't require any language skills, preferbly only english.")
+	}
+	rules = append(rules, "The job should be remote, or provide relocation to the country.")
 
-		2) The job shouldn't require any language skills, preferbly only english.
+	var ruleText strings.Builder
+	for i, rule := range rules {
+		fmt.Fprintf(&ruleText, "\t\t%d) %s\n\n", i+1, rule)
+	}
 
-		3) The job should be remote, or provide relocation to the country.
-	CV:
+	// Build the user message with all the provided data
+	userMessage := fmt.Sprintf(`Analyze the following CV against the job description and criteria, then provide a recommendation following the schema below.
+%s	CV:
 	%s
 	
 	Job Description:
@@ -80,7 +96,7 @@ func (s *OpenRouterService) AnalyzeJobDescription(cv string, jobDesc models.JobD
 	  "experience_match": "excellent" | "good" | "fair" | "poor",
 	  "summary": string,
 	  "improvement_suggestions": [string]
-	}`, cv, jobDesc.Description, jobDesc.Criteria)
+	}`, ruleText.String(), cv, jobDesc.Description, jobDesc.Criteria)
 
 	// Build and execute your request with a fluent API
 	_, resp, err := client.
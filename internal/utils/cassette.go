@@ -0,0 +1,152 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// CassetteMode is whether a cassette stores responses or serves them back
+type CassetteMode string
+
+const (
+	CassetteRecord CassetteMode = "record" // Send requests and store every response
+	CassetteReplay CassetteMode = "replay" // Serve stored responses without network access
+)
+
+// ErrCassetteMiss is returned in replay mode for a request that was never recorded
+var ErrCassetteMiss = errors.New("request not found in cassette")
+
+// Cassette is a directory of recorded HTTP interactions, one JSON file per method and URL
+type Cassette struct {
+	dir  string
+	mode CassetteMode
+}
+
+// cassetteInteraction is the file format of one recorded request
+type cassetteInteraction struct {
+	Request struct {
+		Method string `json:"method"`
+		URL    string `json:"url"`
+	} `json:"request"`
+	Response struct {
+		StatusCode int         `json:"status_code"`
+		Header     http.Header `json:"header,omitempty"`
+		Body       string      `json:"body"`
+	} `json:"response"`
+}
+
+func NewCassette(dir string, mode CassetteMode) (*Cassette, error) {
+	switch mode {
+	case CassetteRecord:
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("error creating cassette directory %s: %w", dir, err)
+		}
+	case CassetteReplay:
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			return nil, fmt.Errorf("cassette directory %s not found", dir)
+		}
+	default:
+		return nil, fmt.Errorf("invalid cassette mode %q, expected record or replay", mode)
+	}
+
+	return &Cassette{dir: dir, mode: mode}, nil
+}
+
+// Client records the responses of next, or replays them without calling next at all
+func (c *Cassette) Client(next HTTPDoer) HTTPDoer {
+	return &cassetteClient{cassette: c, next: next}
+}
+
+// path returns the file of the interaction of a request, named after its method and URL
+func (c *Cassette) path(method, url string) string {
+	sum := sha256.Sum256([]byte(method + " " + url))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:8])+".json")
+}
+
+type cassetteClient struct {
+	cassette *Cassette
+	next     HTTPDoer
+}
+
+func (c *cassetteClient) Do(req *http.Request) (*http.Response, error) {
+	if c.cassette.mode == CassetteReplay {
+		return c.replay(req)
+	}
+	return c.record(req)
+}
+
+func (c *cassetteClient) replay(req *http.Request) (*http.Response, error) {
+	if err := req.Context().Err(); err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(c.cassette.path(req.Method, req.URL.String()))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s %s", ErrCassetteMiss, req.Method, req.URL)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading cassette: %w", err)
+	}
+
+	var interaction cassetteInteraction
+	if err := json.Unmarshal(data, &interaction); err != nil {
+		return nil, fmt.Errorf("error decoding cassette for %s: %w", req.URL, err)
+	}
+
+	header := interaction.Response.Header
+	if header == nil {
+		header = make(http.Header)
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", interaction.Response.StatusCode, http.StatusText(interaction.Response.StatusCode)),
+		StatusCode:    interaction.Response.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader([]byte(interaction.Response.Body))),
+		ContentLength: int64(len(interaction.Response.Body)),
+		Request:       req,
+	}, nil
+}
+
+func (c *cassetteClient) record(req *http.Request) (*http.Response, error) {
+	resp, err := c.next.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("error reading response to record: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	var interaction cassetteInteraction
+	interaction.Request.Method = req.Method
+	interaction.Request.URL = req.URL.String()
+	interaction.Response.StatusCode = resp.StatusCode
+	interaction.Response.Header = resp.Header.Clone()
+	interaction.Response.Header.Del("Set-Cookie")
+	interaction.Response.Body = string(body)
+
+	data, err := json.MarshalIndent(interaction, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("error encoding cassette for %s: %w", req.URL, err)
+	}
+	if err := os.WriteFile(c.cassette.path(req.Method, req.URL.String()), data, 0o644); err != nil {
+		return nil, fmt.Errorf("error writing cassette: %w", err)
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,225 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrCircuitAborted is returned once a host kept blocking requests after every cool-down
+var ErrCircuitAborted = errors.New("circuit breaker aborted requests")
+
+// BreakerState is the state of the circuit breaker of one host
+type BreakerState string
+
+const (
+	BreakerClosed   BreakerState = "closed"    // Requests flow normally
+	BreakerOpen     BreakerState = "open"      // Every request waits for the cool-down to end
+	BreakerHalfOpen BreakerState = "half-open" // One probe request is in flight, the others wait for its outcome
+	BreakerAborted  BreakerState = "aborted"   // The host is still blocking us, every request fails
+)
+
+// BreakerConfig controls when a circuit breaker opens and when it gives up
+type BreakerConfig struct {
+	Threshold int           // Blocked responses in a row that open the breaker, defaults to 5
+	Cooldown  time.Duration // Pause before the probe request, defaults to 2 minutes
+	MaxTrips  int           // Failed probes before every request is aborted, defaults to 3
+	Clock     Clock         // Source of time for cool-downs, the system clock when nil
+}
+
+// BreakerStatus summarizes the breaker of one host for the run summary
+type BreakerStatus struct {
+	Host   string
+	State  BreakerState
+	Opened int // Times the breaker opened
+}
+
+func (s BreakerStatus) String() string {
+	return fmt.Sprintf("%s: %s (opened: %d)", s.Host, s.State, s.Opened)
+}
+
+// CircuitBreaker pauses every request to a host that keeps answering 429 or 999, so workers stop
+// retrying on their own and making the block worse
+type CircuitBreaker struct {
+	config BreakerConfig
+
+	mu      sync.Mutex
+	hosts   map[string]*hostBreaker
+	aborted chan struct{} // Closed when the first host aborts
+	err     error
+}
+
+type hostBreaker struct {
+	state       BreakerState
+	blocks      int           // Blocked responses in a row while closed
+	openedUntil time.Time     // End of the current cool-down
+	opened      int           // Times the breaker opened
+	trips       int           // Probes that were blocked or failed since the breaker last closed
+	changed     chan struct{} // Closed and replaced on every state change
+}
+
+func NewCircuitBreaker(config BreakerConfig) *CircuitBreaker {
+	if config.Threshold <= 0 {
+		config.Threshold = 5
+	}
+	if config.Cooldown <= 0 {
+		config.Cooldown = 2 * time.Minute
+	}
+	if config.MaxTrips <= 0 {
+		config.MaxTrips = 3
+	}
+	if config.Clock == nil {
+		config.Clock = realClock{}
+	}
+
+	return &CircuitBreaker{
+		config:  config,
+		hosts:   make(map[string]*hostBreaker),
+		aborted: make(chan struct{}),
+	}
+}
+
+// Allow blocks while the breaker of host is open or probing, and fails once it aborted
+func (b *CircuitBreaker) Allow(ctx context.Context, host string) error {
+	for {
+		b.mu.Lock()
+		h := b.host(host)
+
+		var wait <-chan time.Time
+		switch h.state {
+		case BreakerClosed:
+			b.mu.Unlock()
+			return nil
+		case BreakerAborted:
+			b.mu.Unlock()
+			return b.abortError(host)
+		case BreakerOpen:
+			remaining := h.openedUntil.Sub(b.config.Clock.Now())
+			if remaining <= 0 {
+				// This request is the probe
+				b.setState(h, BreakerHalfOpen)
+				b.mu.Unlock()
+				return nil
+			}
+			wait = b.config.Clock.After(remaining)
+		}
+		changed := h.changed
+		b.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-changed:
+		case <-wait:
+		}
+	}
+}
+
+// Record updates the breaker of host with the outcome of a request that Allow let through
+func (b *CircuitBreaker) Record(host string, resp *http.Response, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	h := b.host(host)
+
+	if err != nil && (errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)) {
+		// The probe never got an answer, let the next request probe instead
+		if h.state == BreakerHalfOpen {
+			h.openedUntil = b.config.Clock.Now()
+			b.setState(h, BreakerOpen)
+		}
+		return
+	}
+
+	blocked := err == nil && (resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == StatusLinkedInBlocked)
+
+	switch h.state {
+	case BreakerClosed:
+		if !blocked {
+			h.blocks = 0
+			return
+		}
+		h.blocks++
+		if h.blocks >= b.config.Threshold {
+			b.open(host, h)
+		}
+	case BreakerHalfOpen:
+		// A probe that got no response says nothing about the block being lifted
+		if !blocked && err == nil {
+			fmt.Printf("Circuit breaker for %s closed, resuming requests\n", host)
+			h.blocks, h.trips = 0, 0
+			b.setState(h, BreakerClosed)
+			return
+		}
+		h.trips++
+		if h.trips >= b.config.MaxTrips {
+			b.abort(host, h)
+			return
+		}
+		b.open(host, h)
+	}
+}
+
+// Aborted is closed once any host aborts, Err then tells which one
+func (b *CircuitBreaker) Aborted() <-chan struct{} {
+	return b.aborted
+}
+
+// Err returns why the breaker aborted, or nil
+func (b *CircuitBreaker) Err() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.err
+}
+
+// Status returns the breaker of every host that was requested, sorted by host
+func (b *CircuitBreaker) Status() []BreakerStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	statuses := make([]BreakerStatus, 0, len(b.hosts))
+	for host, h := range b.hosts {
+		statuses = append(statuses, BreakerStatus{Host: host, State: h.state, Opened: h.opened})
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Host < statuses[j].Host })
+	return statuses
+}
+
+func (b *CircuitBreaker) host(name string) *hostBreaker {
+	h, ok := b.hosts[name]
+	if !ok {
+		h = &hostBreaker{state: BreakerClosed, changed: make(chan struct{})}
+		b.hosts[name] = h
+	}
+	return h
+}
+
+func (b *CircuitBreaker) open(host string, h *hostBreaker) {
+	h.blocks = 0
+	h.opened++
+	h.openedUntil = b.config.Clock.Now().Add(b.config.Cooldown)
+	fmt.Printf("Circuit breaker for %s opened, pausing requests for %v\n", host, b.config.Cooldown)
+	b.setState(h, BreakerOpen)
+}
+
+func (b *CircuitBreaker) abort(host string, h *hostBreaker) {
+	b.setState(h, BreakerAborted)
+	if b.err == nil {
+		b.err = b.abortError(host)
+		close(b.aborted)
+	}
+}
+
+func (b *CircuitBreaker) abortError(host string) error {
+	return fmt.Errorf("%w: %s still blocked requests after %d cool-downs of %v", ErrCircuitAborted, host, b.config.MaxTrips, b.config.Cooldown)
+}
+
+// setState moves h to state and wakes up the requests waiting on it
+func (b *CircuitBreaker) setState(h *hostBreaker, state BreakerState) {
+	h.state = state
+	close(h.changed)
+	h.changed = make(chan struct{})
+}
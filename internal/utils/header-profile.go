@@ -0,0 +1,74 @@
+package utils
+
+import "net/http"
+
+// HeaderProfile is a consistent set of browser headers, a Chrome User-Agent always comes with
+// Chrome client hints and Firefox or Safari send none
+type HeaderProfile struct {
+	Name            string
+	UserAgent       string
+	Accept          string
+	AcceptLanguage  string
+	SecCHUA         string // Empty for browsers that do not send client hints
+	SecCHUAMobile   string
+	SecCHUAPlatform string
+}
+
+// DefaultHeaderProfiles are recent desktop browsers
+var DefaultHeaderProfiles = []HeaderProfile{
+	{
+		Name:            "chrome-windows",
+		UserAgent:       "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36",
+		Accept:          "text/html,application/xhtml+xml,application/xml;q=0.9,image/avif,image/webp,*/*;q=0.8",
+		AcceptLanguage:  "en-US,en;q=0.9",
+		SecCHUA:         `"Chromium";v="124", "Google Chrome";v="124", "Not-A.Brand";v="99"`,
+		SecCHUAMobile:   "?0",
+		SecCHUAPlatform: `"Windows"`,
+	},
+	{
+		Name:            "chrome-macos",
+		UserAgent:       "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36",
+		Accept:          "text/html,application/xhtml+xml,application/xml;q=0.9,image/avif,image/webp,*/*;q=0.8",
+		AcceptLanguage:  "en-GB,en;q=0.9,ja;q=0.8",
+		SecCHUA:         `"Chromium";v="124", "Google Chrome";v="124", "Not-A.Brand";v="99"`,
+		SecCHUAMobile:   "?0",
+		SecCHUAPlatform: `"macOS"`,
+	},
+	{
+		Name:            "edge-windows",
+		UserAgent:       "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36 Edg/124.0.0.0",
+		Accept:          "text/html,application/xhtml+xml,application/xml;q=0.9,image/avif,image/webp,*/*;q=0.8",
+		AcceptLanguage:  "en-US,en;q=0.9",
+		SecCHUA:         `"Chromium";v="124", "Microsoft Edge";v="124", "Not-A.Brand";v="99"`,
+		SecCHUAMobile:   "?0",
+		SecCHUAPlatform: `"Windows"`,
+	},
+	{
+		Name:           "firefox-windows",
+		UserAgent:      "Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:125.0) Gecko/20100101 Firefox/125.0",
+		Accept:         "text/html,application/xhtml+xml,application/xml;q=0.9,image/avif,image/webp,*/*;q=0.8",
+		AcceptLanguage: "en-US,en;q=0.5",
+	},
+	{
+		Name:           "safari-macos",
+		UserAgent:      "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4 Safari/605.1.15",
+		Accept:         "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8",
+		AcceptLanguage: "en-US,en;q=0.9",
+	},
+}
+
+// Apply sets the headers of the profile on h
+func (p HeaderProfile) Apply(h http.Header) {
+	h.Set("User-Agent", p.UserAgent)
+	if p.Accept != "" {
+		h.Set("Accept", p.Accept)
+	}
+	if p.AcceptLanguage != "" {
+		h.Set("Accept-Language", p.AcceptLanguage)
+	}
+	if p.SecCHUA != "" {
+		h.Set("Sec-CH-UA", p.SecCHUA)
+		h.Set("Sec-CH-UA-Mobile", p.SecCHUAMobile)
+		h.Set("Sec-CH-UA-Platform", p.SecCHUAPlatform)
+	}
+}
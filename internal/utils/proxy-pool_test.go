@@ -0,0 +1,106 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func newTestProxyPool(t *testing.T, clock *fakeClock, maxFailures int) *ProxyPool {
+	t.Helper()
+
+	pool, err := NewProxyPool([]string{"http://proxy-a:8080", "http://proxy-b:8080"}, ProxyPoolConfig{
+		MaxFailures: maxFailures,
+		Cooldown:    10 * time.Minute,
+		Clock:       clock,
+	})
+	if err != nil {
+		t.Fatalf("NewProxyPool: %v", err)
+	}
+	return pool
+}
+
+func blockedResponse() *http.Response {
+	return &http.Response{StatusCode: http.StatusTooManyRequests}
+}
+
+func TestProxyPoolWaitsForTheSoonestProxy(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)}
+	pool := newTestProxyPool(t, clock, 1)
+
+	first, _ := pool.Next()
+	pool.Report(first, blockedResponse(), nil)
+	clock.now = clock.now.Add(4 * time.Minute)
+	second, _ := pool.Next()
+	pool.Report(second, blockedResponse(), nil)
+
+	if _, err := pool.Next(); !errors.Is(err, ErrNoHealthyProxy) {
+		t.Fatalf("Next with every proxy evicted returned %v, want ErrNoHealthyProxy", err)
+	}
+
+	proxy, err := pool.Wait(context.Background())
+	if err != nil {
+		t.Fatalf("Wait: %v", err)
+	}
+	if proxy != first {
+		t.Errorf("Wait returned %s, want the first proxy evicted", proxy.URL)
+	}
+	if want := []time.Duration{6 * time.Minute}; !reflect.DeepEqual(clock.waits, want) {
+		t.Errorf("waited %v, want %v", clock.waits, want)
+	}
+}
+
+// stoppedClock never moves and never fires
+type stoppedClock struct{}
+
+func (stoppedClock) Now() time.Time { return time.Time{} }
+
+func (stoppedClock) After(d time.Duration) <-chan time.Time { return nil }
+
+func TestProxyPoolWaitStopsWithTheContext(t *testing.T) {
+	pool := newTestProxyPool(t, &fakeClock{}, 1)
+	for range 2 {
+		proxy, _ := pool.Next()
+		pool.Report(proxy, blockedResponse(), nil)
+	}
+
+	// A clock that never fires, only the cancelled context ends the wait
+	pool.config.Clock = stoppedClock{}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := pool.Wait(ctx); !errors.Is(err, ErrNoHealthyProxy) || !errors.Is(err, context.Canceled) {
+		t.Errorf("Wait with a cancelled context returned %v", err)
+	}
+}
+
+func TestProxyPoolEvictsOnlyOnBlockedResponses(t *testing.T) {
+	pool := newTestProxyPool(t, &fakeClock{}, 2)
+	proxy := pool.proxies[0]
+
+	// Connection failures neither count against the proxy nor clear its record
+	pool.Report(proxy, blockedResponse(), nil)
+	for range 5 {
+		pool.Report(proxy, nil, syscall.ECONNRESET)
+	}
+	if !proxy.evictedUntil.IsZero() {
+		t.Fatal("proxy evicted after one blocked response and connection failures")
+	}
+
+	pool.Report(proxy, blockedResponse(), nil)
+	if proxy.evictedUntil.IsZero() {
+		t.Error("proxy not evicted after two blocked responses")
+	}
+
+	other := pool.proxies[1]
+	pool.Report(other, blockedResponse(), nil)
+	pool.Report(other, &http.Response{StatusCode: http.StatusOK}, nil)
+	pool.Report(other, blockedResponse(), nil)
+	if !other.evictedUntil.IsZero() {
+		t.Error("proxy evicted although a successful response came between its blocked ones")
+	}
+}
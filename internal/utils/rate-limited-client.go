@@ -0,0 +1,125 @@
+package utils
+
+import (
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// HTTPDoer sends HTTP requests, *http.Client and *RateLimitedClient both implement it
+type HTTPDoer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// HostLimits bound the requests sent to each host
+type HostLimits struct {
+	Interval      time.Duration // Minimum time between requests to a host, 0 for no limit
+	Burst         int           // Requests that may be sent at once before Interval applies, at least 1
+	MaxConcurrent int           // Requests in flight to a host at the same time, 0 for no cap
+}
+
+// RateLimitedClient is an http.Client shared by all workers which keeps one token bucket and one
+// concurrency cap per host, so adding workers does not increase the request rate
+type RateLimitedClient struct {
+	client *http.Client
+
+	mu     sync.Mutex
+	limits HostLimits
+	hosts  map[string]*hostLimiter
+}
+
+type hostLimiter struct {
+	limiter *rate.Limiter
+	slots   chan struct{} // nil when concurrency is not capped
+}
+
+func NewRateLimitedClient(timeout time.Duration, limits HostLimits) *RateLimitedClient {
+	if limits.Burst < 1 {
+		limits.Burst = 1
+	}
+
+	return &RateLimitedClient{
+		client: &http.Client{Timeout: timeout, Transport: newTransport()},
+		limits: limits,
+		hosts:  make(map[string]*hostLimiter),
+	}
+}
+
+// SetInterval changes the minimum time between requests to each host
+func (c *RateLimitedClient) SetInterval(interval time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.limits.Interval = interval
+	for _, host := range c.hosts {
+		host.limiter.SetLimit(intervalLimit(interval))
+	}
+}
+
+// Do waits for the host's rate budget and a free slot, then sends req. The slot is held until
+// the response body is closed.
+func (c *RateLimitedClient) Do(req *http.Request) (*http.Response, error) {
+	host := c.host(req.URL.Host)
+
+	if err := host.limiter.Wait(req.Context()); err != nil {
+		return nil, err
+	}
+
+	if host.slots != nil {
+		select {
+		case host.slots <- struct{}{}:
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+	}
+
+	resp, err := c.client.Do(req)
+	if host.slots == nil {
+		return resp, err
+	}
+	if err != nil {
+		<-host.slots
+		return nil, err
+	}
+
+	resp.Body = &releaseOnClose{ReadCloser: resp.Body, release: func() { <-host.slots }}
+	return resp, nil
+}
+
+func (c *RateLimitedClient) host(name string) *hostLimiter {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	host, ok := c.hosts[name]
+	if !ok {
+		host = &hostLimiter{limiter: rate.NewLimiter(intervalLimit(c.limits.Interval), c.limits.Burst)}
+		if c.limits.MaxConcurrent > 0 {
+			host.slots = make(chan struct{}, c.limits.MaxConcurrent)
+		}
+		c.hosts[name] = host
+	}
+	return host
+}
+
+func intervalLimit(interval time.Duration) rate.Limit {
+	if interval <= 0 {
+		return rate.Inf
+	}
+	return rate.Every(interval)
+}
+
+// releaseOnClose frees a concurrency slot once the response body is closed
+type releaseOnClose struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+func (r *releaseOnClose) Close() error {
+	err := r.ReadCloser.Close()
+	r.once.Do(r.release)
+	return err
+}
@@ -0,0 +1,106 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Request describes an HTTP request that can be sent once per retry attempt
+type Request struct {
+	Method  string
+	URL     string
+	Header  http.Header               // Set after the browser header profile, so it overrides it
+	Body    []byte                    // Sent again with every attempt
+	GetBody func() (io.Reader, error) // Builds a new body for every attempt, used when Body is nil
+	Timeout time.Duration             // Limit of each attempt including reading the body, 0 for the client timeout
+
+	Revalidate bool          // Asks the server even while the cached response is fresh
+	MaxAge     time.Duration // Cached responses older than this are revalidated, 0 keeps the route TTL
+}
+
+// NewRequest starts a request with no body
+func NewRequest(method, url string) *Request {
+	return &Request{Method: method, URL: url, Header: make(http.Header)}
+}
+
+// WithHeader sets a header of the request
+func (r *Request) WithHeader(key, value string) *Request {
+	if r.Header == nil {
+		r.Header = make(http.Header)
+	}
+	r.Header.Set(key, value)
+	return r
+}
+
+// WithBody sends body with every attempt
+func (r *Request) WithBody(contentType string, body []byte) *Request {
+	r.Body, r.GetBody = body, nil
+	return r.WithHeader("Content-Type", contentType)
+}
+
+// WithBodyFunc calls getBody before every attempt, for bodies that are streamed or signed
+func (r *Request) WithBodyFunc(contentType string, getBody func() (io.Reader, error)) *Request {
+	r.Body, r.GetBody = nil, getBody
+	return r.WithHeader("Content-Type", contentType)
+}
+
+// WithJSONBody sends v encoded as JSON
+func (r *Request) WithJSONBody(v any) (*Request, error) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding request body: %w", err)
+	}
+	return r.WithBody("application/json", body), nil
+}
+
+// WithTimeout limits each attempt to timeout
+func (r *Request) WithTimeout(timeout time.Duration) *Request {
+	r.Timeout = timeout
+	return r
+}
+
+// WithRevalidation skips fresh cached responses, the server can still answer 304 Not Modified
+func (r *Request) WithRevalidation() *Request {
+	r.Revalidate = true
+	return r
+}
+
+// WithMaxAge revalidates cached responses older than maxAge even when their route keeps them
+// fresh longer
+func (r *Request) WithMaxAge(maxAge time.Duration) *Request {
+	r.MaxAge = maxAge
+	return r
+}
+
+// build creates the http.Request of one attempt with a fresh body
+func (r *Request) build(ctx context.Context) (*http.Request, error) {
+	var body io.Reader
+	switch {
+	case r.Body != nil:
+		body = bytes.NewReader(r.Body)
+	case r.GetBody != nil:
+		var err error
+		if body, err = r.GetBody(); err != nil {
+			return nil, fmt.Errorf("error building request body: %w", err)
+		}
+	}
+
+	return http.NewRequestWithContext(ctx, r.Method, r.URL, body)
+}
+
+// cancelOnClose ends the context of an attempt with a timeout once its body was read
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnClose) Close() error {
+	err := c.ReadCloser.Close()
+	c.cancel()
+	return err
+}
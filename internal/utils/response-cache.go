@@ -0,0 +1,230 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// trackingParams are query parameters that change between listings of the same page
+var trackingParams = map[string]bool{
+	"trk":               true,
+	"trackingId":        true,
+	"refId":             true,
+	"position":          true,
+	"pageNum":           true,
+	"lipi":              true,
+	"originalSubdomain": true,
+}
+
+// CacheRoute sets how long responses of the URLs matching Pattern stay fresh
+type CacheRoute struct {
+	Pattern *regexp.Regexp // Matched against the host and path of the normalized URL
+	TTL     time.Duration
+}
+
+// NewCacheRoute compiles pattern, e.g. `linkedin\.com/jobs/view/`
+func NewCacheRoute(pattern string, ttl time.Duration) CacheRoute {
+	return CacheRoute{Pattern: regexp.MustCompile(pattern), TTL: ttl}
+}
+
+// CacheConfig configures an on-disk response cache
+type CacheConfig struct {
+	Dir    string       // Directory the responses are stored in
+	Routes []CacheRoute // First matching route wins, URLs matching no route are not cached
+	Clock  Clock        // Source of time for freshness, the system clock when nil
+}
+
+// ResponseCache stores successful GET responses on disk and revalidates stale ones with
+// If-None-Match and If-Modified-Since when the server sent an ETag or Last-Modified
+type ResponseCache struct {
+	config CacheConfig
+}
+
+// cacheEntry is the file format of one cached response
+type cacheEntry struct {
+	URL          string      `json:"url"`
+	StatusCode   int         `json:"status_code"`
+	Header       http.Header `json:"header,omitempty"`
+	Body         string      `json:"body"`
+	ETag         string      `json:"etag,omitempty"`
+	LastModified string      `json:"last_modified,omitempty"`
+	StoredAt     time.Time   `json:"stored_at"`
+}
+
+func NewResponseCache(config CacheConfig) (*ResponseCache, error) {
+	if config.Clock == nil {
+		config.Clock = realClock{}
+	}
+	if err := os.MkdirAll(config.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("error creating cache directory %s: %w", config.Dir, err)
+	}
+
+	return &ResponseCache{config: config}, nil
+}
+
+// NormalizeURL lowercases the scheme and host, drops the fragment and tracking parameters and
+// sorts the query, so the same page listed twice maps to the same cache entry
+func NormalizeURL(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+
+	parsed.Scheme = strings.ToLower(parsed.Scheme)
+	parsed.Host = strings.ToLower(parsed.Host)
+	parsed.Fragment = ""
+
+	query := parsed.Query()
+	for key := range query {
+		if trackingParams[key] || strings.HasPrefix(key, "utm_") {
+			query.Del(key)
+		}
+	}
+	// Encode sorts by key
+	parsed.RawQuery = query.Encode()
+
+	return parsed.String()
+}
+
+// ttl returns how long the response of the normalized URL stays fresh, 0 when it is not cached
+func (c *ResponseCache) ttl(normalized string) time.Duration {
+	parsed, err := url.Parse(normalized)
+	if err != nil {
+		return 0
+	}
+
+	target := parsed.Host + parsed.Path
+	for _, route := range c.config.Routes {
+		if route.Pattern.MatchString(target) {
+			return route.TTL
+		}
+	}
+	return 0
+}
+
+func (c *ResponseCache) path(normalized string) string {
+	sum := sha256.Sum256([]byte(normalized))
+	return filepath.Join(c.config.Dir, hex.EncodeToString(sum[:8])+".json")
+}
+
+// lookup returns the stored entry of rawURL and whether it is still fresh. A nil entry means
+// the URL is not cached. A positive maxAge shortens the TTL of the route.
+func (c *ResponseCache) lookup(rawURL string, maxAge time.Duration) (*cacheEntry, bool) {
+	normalized := NormalizeURL(rawURL)
+	ttl := c.ttl(normalized)
+	if ttl <= 0 {
+		return nil, false
+	}
+	if maxAge > 0 {
+		ttl = min(ttl, maxAge)
+	}
+
+	data, err := os.ReadFile(c.path(normalized))
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			fmt.Printf("Error reading cached response for %s: %v\n", rawURL, err)
+		}
+		return nil, false
+	}
+
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		fmt.Printf("Error decoding cached response for %s: %v\n", rawURL, err)
+		return nil, false
+	}
+
+	return &entry, c.config.Clock.Now().Sub(entry.StoredAt) < ttl
+}
+
+// store saves resp when its URL is cached and returns it with a body that can still be read
+func (c *ResponseCache) store(rawURL string, resp *http.Response) (*http.Response, error) {
+	normalized := NormalizeURL(rawURL)
+	if resp.StatusCode != http.StatusOK || c.ttl(normalized) <= 0 {
+		return resp, nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("error reading response body: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	entry := cacheEntry{
+		URL:          normalized,
+		StatusCode:   resp.StatusCode,
+		Header:       http.Header{"Content-Type": resp.Header.Values("Content-Type")},
+		Body:         string(body),
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		StoredAt:     c.config.Clock.Now(),
+	}
+	c.write(&entry)
+
+	return resp, nil
+}
+
+// revalidated marks a stale entry as fresh again after the server answered 304 Not Modified
+func (c *ResponseCache) revalidated(entry *cacheEntry) {
+	entry.StoredAt = c.config.Clock.Now()
+	c.write(entry)
+}
+
+// write stores entry, a cache that cannot be written only costs a refetch so errors are logged
+func (c *ResponseCache) write(entry *cacheEntry) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		fmt.Printf("Error encoding cached response for %s: %v\n", entry.URL, err)
+		return
+	}
+	if err := os.WriteFile(c.path(entry.URL), data, 0o644); err != nil {
+		fmt.Printf("Error writing cached response for %s: %v\n", entry.URL, err)
+	}
+}
+
+// conditionalHeaders returns the validators to revalidate entry with, nil when it has none
+func (e *cacheEntry) conditionalHeaders() http.Header {
+	if e.ETag == "" && e.LastModified == "" {
+		return nil
+	}
+
+	header := make(http.Header)
+	if e.ETag != "" {
+		header.Set("If-None-Match", e.ETag)
+	}
+	if e.LastModified != "" {
+		header.Set("If-Modified-Since", e.LastModified)
+	}
+	return header
+}
+
+func (e *cacheEntry) response(req *http.Request) *http.Response {
+	header := e.Header.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", e.StatusCode, http.StatusText(e.StatusCode)),
+		StatusCode:    e.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(e.Body)),
+		ContentLength: int64(len(e.Body)),
+		Request:       req,
+	}
+}
@@ -0,0 +1,102 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// StatusLinkedInBlocked is the non-standard status LinkedIn answers with when it blocks a client
+const StatusLinkedInBlocked = 999
+
+// Clock is the source of time used between retries, so schedules can be driven by a fake clock
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// RetryClassifier decides whether a request is worth trying again, resp is nil when err is set
+type RetryClassifier func(resp *http.Response, err error) bool
+
+// DefaultRetryClassifier retries server errors, rate limiting, LinkedIn blocks and dropped
+// connections. Cancelled requests and other client errors are not retried.
+func DefaultRetryClassifier(resp *http.Response, err error) bool {
+	if err != nil {
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return false
+		}
+		return errors.Is(err, syscall.ECONNRESET) ||
+			errors.Is(err, syscall.ECONNREFUSED) ||
+			errors.Is(err, syscall.EPIPE) ||
+			errors.Is(err, io.ErrUnexpectedEOF) ||
+			errors.Is(err, io.EOF) ||
+			strings.Contains(err.Error(), "connection reset by peer")
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return true
+	case resp.StatusCode == StatusLinkedInBlocked:
+		return true
+	case resp.StatusCode >= http.StatusInternalServerError && resp.StatusCode <= 599:
+		return true
+	}
+	return false
+}
+
+// backoff returns the full jitter delay before retry number attempt (0-based): a random wait
+// between 0 and BaseDelay * 2^attempt, capped at MaxDelay
+func (c RetryConfig) backoff(attempt int) time.Duration {
+	ceiling := c.MaxDelay
+	if c.BaseDelay > 0 && attempt < 62 {
+		if d := c.BaseDelay << attempt; d > 0 && (ceiling <= 0 || d < ceiling) {
+			ceiling = d
+		}
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+
+	if c.Jitter != nil {
+		return c.Jitter(ceiling)
+	}
+	return time.Duration(rand.Int64N(int64(ceiling) + 1))
+}
+
+// retryAfter reads the Retry-After header of resp, given either in seconds or as an HTTP date
+func retryAfter(resp *http.Response, now time.Time) (time.Duration, bool) {
+	if resp == nil {
+		return 0, false
+	}
+	value := strings.TrimSpace(resp.Header.Get("Retry-After"))
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		if wait := date.Sub(now); wait > 0 {
+			return wait, true
+		}
+		return 0, true
+	}
+
+	return 0, false
+}
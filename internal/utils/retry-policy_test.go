@@ -0,0 +1,183 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// fakeClock never sleeps, it records every wait and fires it at once, moving its time forward
+type fakeClock struct {
+	now   time.Time
+	waits []time.Duration
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.waits = append(c.waits, d)
+	c.now = c.now.Add(d)
+	fired := make(chan time.Time, 1)
+	fired <- c.now
+	return fired
+}
+
+// scriptedResponse is the outcome of one attempt, a status with headers or a connection error
+type scriptedResponse struct {
+	status int
+	header http.Header
+	err    error
+}
+
+// scriptedDoer answers each attempt with the next scripted response
+type scriptedDoer struct {
+	responses []scriptedResponse
+	attempts  int
+}
+
+func (d *scriptedDoer) Do(req *http.Request) (*http.Response, error) {
+	if d.attempts >= len(d.responses) {
+		return nil, errors.New("unexpected attempt")
+	}
+	r := d.responses[d.attempts]
+	d.attempts++
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	header := r.header
+	if header == nil {
+		header = make(http.Header)
+	}
+	return &http.Response{
+		StatusCode: r.status,
+		Status:     http.StatusText(r.status),
+		Header:     header,
+		Body:       io.NopCloser(strings.NewReader("")),
+		Request:    req,
+	}, nil
+}
+
+func retryAfterHeader(value string) http.Header {
+	return http.Header{"Retry-After": []string{value}}
+}
+
+func TestRetrySchedule(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	fullCeiling := func(max time.Duration) time.Duration { return max }
+
+	tests := []struct {
+		name       string
+		maxRetries int
+		jitter     func(max time.Duration) time.Duration
+		responses  []scriptedResponse
+		wantWaits  []time.Duration
+		wantStatus int // Status of the returned error, 0 when the request succeeds
+	}{
+		{
+			name:       "exponential backoff capped at MaxDelay",
+			maxRetries: 4,
+			jitter:     fullCeiling,
+			responses:  []scriptedResponse{{status: 503}, {status: 500}, {status: 502}, {status: 999}, {status: 200}},
+			wantWaits:  []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second},
+		},
+		{
+			name:       "jitter picks within the ceiling",
+			maxRetries: 3,
+			jitter:     func(max time.Duration) time.Duration { return max / 4 },
+			responses:  []scriptedResponse{{status: 503}, {status: 503}, {status: 503}, {status: 200}},
+			wantWaits:  []time.Duration{250 * time.Millisecond, 500 * time.Millisecond, time.Second},
+		},
+		{
+			name:       "Retry-After in seconds replaces the backoff",
+			maxRetries: 3,
+			jitter:     fullCeiling,
+			responses:  []scriptedResponse{{status: 429, header: retryAfterHeader("7")}, {status: 503}, {status: 200}},
+			wantWaits:  []time.Duration{7 * time.Second, 2 * time.Second},
+		},
+		{
+			name:       "Retry-After as an HTTP date",
+			maxRetries: 3,
+			jitter:     fullCeiling,
+			responses:  []scriptedResponse{{status: 503, header: retryAfterHeader(now.Add(30 * time.Second).Format(http.TimeFormat))}, {status: 200}},
+			wantWaits:  []time.Duration{30 * time.Second},
+		},
+		{
+			name:       "Retry-After date in the past retries at once",
+			maxRetries: 3,
+			jitter:     fullCeiling,
+			responses:  []scriptedResponse{{status: 429, header: retryAfterHeader(now.Add(-time.Minute).Format(http.TimeFormat))}, {status: 200}},
+			wantWaits:  []time.Duration{0},
+		},
+		{
+			name:       "invalid Retry-After keeps the backoff",
+			maxRetries: 3,
+			jitter:     fullCeiling,
+			responses:  []scriptedResponse{{status: 429, header: retryAfterHeader("soon")}, {status: 429, header: retryAfterHeader("-5")}, {status: 200}},
+			wantWaits:  []time.Duration{time.Second, 2 * time.Second},
+		},
+		{
+			name:       "dropped connections are retried",
+			maxRetries: 3,
+			jitter:     fullCeiling,
+			responses:  []scriptedResponse{{err: syscall.ECONNRESET}, {err: io.ErrUnexpectedEOF}, {status: 200}},
+			wantWaits:  []time.Duration{time.Second, 2 * time.Second},
+		},
+		{
+			name:       "client errors are not retried",
+			maxRetries: 3,
+			jitter:     fullCeiling,
+			responses:  []scriptedResponse{{status: 404}},
+			wantStatus: 404,
+		},
+		{
+			name:       "no wait after the last attempt",
+			maxRetries: 2,
+			jitter:     fullCeiling,
+			responses:  []scriptedResponse{{status: 503}, {status: 429, header: retryAfterHeader("60")}, {status: 503}},
+			wantWaits:  []time.Duration{time.Second, time.Minute},
+			wantStatus: 503,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := &fakeClock{now: now}
+			doer := &scriptedDoer{responses: tt.responses}
+			request := NewRetryableHTTPRequestWithClient(doer, RetryConfig{
+				MaxRetries: tt.maxRetries,
+				BaseDelay:  time.Second,
+				MaxDelay:   5 * time.Second,
+				Clock:      clock,
+				Jitter:     tt.jitter,
+			})
+
+			resp, err := request.RetryableHTTPRequest(context.Background(), NewRequest(http.MethodGet, "https://example.com/jobs"))
+
+			if tt.wantStatus == 0 {
+				if err != nil {
+					t.Fatalf("RetryableHTTPRequest: %v", err)
+				}
+				resp.Body.Close()
+			} else {
+				var statusErr *StatusError
+				if !errors.As(err, &statusErr) || statusErr.StatusCode != tt.wantStatus {
+					t.Fatalf("RetryableHTTPRequest error = %v, want status %d", err, tt.wantStatus)
+				}
+			}
+
+			if !reflect.DeepEqual(clock.waits, tt.wantWaits) {
+				t.Errorf("waited %v, want %v", clock.waits, tt.wantWaits)
+			}
+			if doer.attempts != len(tt.responses) {
+				t.Errorf("made %d attempts, want %d", doer.attempts, len(tt.responses))
+			}
+		})
+	}
+}
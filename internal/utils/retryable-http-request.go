@@ -3,8 +3,8 @@ package utils
 import (
 	"context"
 	"fmt"
-	"io"
 	"net/http"
+	"sync"
 	"time"
 )
 
@@ -12,68 +12,256 @@ type RetryConfig struct {
 	MaxRetries int
 	BaseDelay  time.Duration
 	MaxDelay   time.Duration
+
+	Classifier RetryClassifier                       // Decides which failures are retried, DefaultRetryClassifier when nil
+	Clock      Clock                                 // Waits between attempts, the system clock when nil
+	Jitter     func(max time.Duration) time.Duration // Picks a wait in [0, max], uniformly random when nil
+}
+
+// StatusError is returned for a response that was not successful
+type StatusError struct {
+	StatusCode int
+	Status     string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("request failed %d: %s", e.StatusCode, e.Status)
 }
 
 type RetryableHTTPRequestImpl struct {
-	client *http.Client
+	client HTTPDoer
 	config RetryConfig
+
+	mu       sync.Mutex
+	proxies  *ProxyPool      // Proxies requests are sent through, nil for direct requests
+	profiles []HeaderProfile // Browser headers rotated between requests
+	next     int             // Profile used by the next direct request
+	breaker  *CircuitBreaker // Pauses requests to hosts that block us, nil to never pause
+	cache    *ResponseCache  // Serves and revalidates GET responses, nil to always fetch
+}
+
+// attemptIdentity is what an attempt is sent with
+type attemptIdentity struct {
+	client  HTTPDoer
+	proxy   *Proxy
+	profile HeaderProfile
+	breaker *CircuitBreaker
 }
 
 func NewRetryableHTTPRequest(config RetryConfig) *RetryableHTTPRequestImpl {
 	client := &http.Client{
-		Timeout: 30 * time.Second,
+		Timeout:   30 * time.Second,
+		Transport: newTransport(),
+	}
+
+	return NewRetryableHTTPRequestWithClient(client, config)
+}
+
+// NewRetryableHTTPRequestWithClient retries requests sent through client, which is usually a
+// RateLimitedClient shared by all callers
+func NewRetryableHTTPRequestWithClient(client HTTPDoer, config RetryConfig) *RetryableHTTPRequestImpl {
+	if config.Classifier == nil {
+		config.Classifier = DefaultRetryClassifier
+	}
+	if config.Clock == nil {
+		config.Clock = realClock{}
 	}
 
 	return &RetryableHTTPRequestImpl{
-		client: client,
-		config: config,
+		client:   client,
+		config:   config,
+		profiles: DefaultHeaderProfiles,
+	}
+}
+
+// SetProxyPool sends every following request through a proxy of pool
+func (s *RetryableHTTPRequestImpl) SetProxyPool(pool *ProxyPool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.proxies = pool
+}
+
+// SetHeaderProfiles replaces the browser header profiles rotated between requests
+func (s *RetryableHTTPRequestImpl) SetHeaderProfiles(profiles []HeaderProfile) {
+	if len(profiles) == 0 {
+		return
 	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.profiles = profiles
+}
+
+// UseResponseCache serves every following GET request from cache while it is fresh
+func (s *RetryableHTTPRequestImpl) UseResponseCache(cache *ResponseCache) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cache = cache
+}
+
+// UseCassette records the responses of every following request to cassette, or replays them
+func (s *RetryableHTTPRequestImpl) UseCassette(cassette *Cassette) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.client = cassette.Client(s.client)
 }
 
-func (s *RetryableHTTPRequestImpl) RetryableHTTPRequest(ctx context.Context, url, method string, body io.Reader, headers []http.Header) (*http.Response, error) {
+// SetCircuitBreaker makes every following request go through breaker
+func (s *RetryableHTTPRequestImpl) SetCircuitBreaker(breaker *CircuitBreaker) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.breaker = breaker
+}
+
+// identity picks the client, the proxy, the header profile and the breaker of an attempt. A
+// proxy always presents the same profile, so one IP does not switch browsers between requests.
+// While every proxy is cooling down it waits for the first one to come back.
+func (s *RetryableHTTPRequestImpl) identity(ctx context.Context) (attemptIdentity, error) {
+	s.mu.Lock()
+	proxies := s.proxies
+	s.mu.Unlock()
+
+	var proxy *Proxy
+	if proxies != nil {
+		var err error
+		if proxy, err = proxies.Wait(ctx); err != nil {
+			return attemptIdentity{}, err
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := attemptIdentity{client: s.client, breaker: s.breaker}
+	if proxy == nil {
+		id.profile = s.profiles[s.next%len(s.profiles)]
+		s.next++
+		return id, nil
+	}
+	id.proxy, id.profile = proxy, s.profiles[proxy.index%len(s.profiles)]
+	return id, nil
+}
+
+// RetryableHTTPRequest sends request, retrying failures the classifier accepts. GET responses
+// come from the response cache while they are fresh, unless the request asks to revalidate or
+// the response is older than its MaxAge.
+func (s *RetryableHTTPRequestImpl) RetryableHTTPRequest(ctx context.Context, request *Request) (*http.Response, error) {
+	s.mu.Lock()
+	cache := s.cache
+	s.mu.Unlock()
+
+	if cache == nil || request.Method != http.MethodGet {
+		return s.send(ctx, request, nil)
+	}
+
+	entry, fresh := cache.lookup(request.URL, request.MaxAge)
+	if fresh && !request.Revalidate {
+		return entry.response(nil), nil
+	}
+
+	var conditional http.Header
+	if entry != nil {
+		conditional = entry.conditionalHeaders()
+	}
+
+	resp, err := s.send(ctx, request, conditional)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotModified {
+		resp.Body.Close()
+		cache.revalidated(entry)
+		return entry.response(resp.Request), nil
+	}
+
+	return cache.store(request.URL, resp)
+}
+
+// send runs the attempts of a request. A 304 answer to conditional headers counts as success.
+func (s *RetryableHTTPRequestImpl) send(ctx context.Context, request *Request, conditional http.Header) (*http.Response, error) {
 
 	var lastErr error
 
 	for attempt := 0; attempt <= s.config.MaxRetries; attempt++ {
-		req, err := http.NewRequestWithContext(ctx, method, url, body)
+		id, err := s.identity(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		req, err := request.build(ctx)
 
 		if err != nil {
 			return nil, fmt.Errorf("failed to create request: %w", err)
 		}
 
-		req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36")
-		for _, header := range headers {
-			req.Header.Set(header.Get("Key"), header.Get("Value"))
+		id.profile.Apply(req.Header)
+		for key, values := range request.Header {
+			req.Header[key] = values
+		}
+		for key, values := range conditional {
+			req.Header[key] = values
 		}
 
-		resp, err := s.client.Do(req)
+		// Wait while the host is blocking us, the breaker fails once it gave up on the host
+		if id.breaker != nil {
+			if err := id.breaker.Allow(ctx, req.URL.Host); err != nil {
+				return nil, err
+			}
+		}
+
+		// The timeout only covers the attempt, not the wait for the breaker
+		attemptCtx, cancel := ctx, context.CancelFunc(func() {})
+		if request.Timeout > 0 {
+			attemptCtx, cancel = context.WithTimeout(ctx, request.Timeout)
+		}
+		req = req.WithContext(withProxy(attemptCtx, id.proxy))
+
+		resp, err := id.client.Do(req)
+		if id.proxy != nil {
+			id.proxy.pool.Report(id.proxy, resp, err)
+		}
+		if id.breaker != nil {
+			id.breaker.Record(req.URL.Host, resp, err)
+		}
+		success := err == nil && resp.StatusCode >= http.StatusOK && resp.StatusCode < http.StatusMultipleChoices
+		// A 304 means the cached copy is still valid
+		notModified := err == nil && resp.StatusCode == http.StatusNotModified && conditional != nil
+		if success || notModified {
+			resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+			return resp, nil
+		}
+
+		if !s.config.Classifier(resp, err) {
+			defer cancel()
+			if err != nil {
+				return nil, fmt.Errorf("request failed: %w", err)
+			}
+			resp.Body.Close()
+			return nil, &StatusError{StatusCode: resp.StatusCode, Status: resp.Status}
+		}
+
+		// Retryable failure, the server may tell us how long to wait
+		delay := s.config.backoff(attempt)
 		if err != nil {
 			lastErr = err
 			fmt.Printf("Request attempt %d failed: %v\n", attempt+1, err)
-		} else if resp.StatusCode >= http.StatusOK && resp.StatusCode < http.StatusMultipleChoices {
-			// Success
-			return resp, nil
-		} else if resp.StatusCode == http.StatusTooManyRequests {
-			// 429 Too Many Requests - retry with backoff
-			resp.Body.Close()
-			lastErr = fmt.Errorf("rate limited: %d %s", resp.StatusCode, resp.Status)
-			fmt.Printf("Request attempt %d failed with status %d (rate limited)\n", attempt+1, resp.StatusCode)
 		} else {
-			// All other errors (4xx, 5xx) - don't retry
+			if wait, ok := retryAfter(resp, s.config.Clock.Now()); ok {
+				delay = wait
+			}
 			resp.Body.Close()
-			return nil, fmt.Errorf("request failed %d: %s", resp.StatusCode, resp.Status)
+			lastErr = &StatusError{StatusCode: resp.StatusCode, Status: resp.Status}
+			fmt.Printf("Request attempt %d failed with status %d\n", attempt+1, resp.StatusCode)
 		}
+		cancel()
 
-		// max attempts for now = 3
 		if attempt < s.config.MaxRetries {
-			delay := time.Second * 2
-
 			fmt.Printf("Retrying in %v... (attempt %d/%d)\n", delay, attempt+1, s.config.MaxRetries)
 
 			select {
 			case <-ctx.Done():
 				return nil, ctx.Err()
-			case <-time.After(delay):
+			case <-s.config.Clock.After(delay):
 				// Continue to next attempt
 			}
 		}
@@ -0,0 +1,18 @@
+DROP INDEX IF EXISTS idx_jobs_source;
+
+ALTER TABLE job_descriptions DROP CONSTRAINT IF EXISTS job_descriptions_job_fkey;
+ALTER TABLE job_descriptions DROP CONSTRAINT IF EXISTS unique_job_id;
+
+ALTER TABLE jobs DROP CONSTRAINT IF EXISTS unique_job_link;
+ALTER TABLE jobs ADD CONSTRAINT jobs_job_link_key UNIQUE (job_link);
+ALTER TABLE jobs ADD CONSTRAINT unique_job_link UNIQUE (job_link);
+
+ALTER TABLE jobs DROP CONSTRAINT IF EXISTS jobs_pkey;
+ALTER TABLE jobs ADD CONSTRAINT jobs_pkey PRIMARY KEY (id);
+
+ALTER TABLE job_descriptions ADD CONSTRAINT unique_job_id UNIQUE (job_id);
+ALTER TABLE job_descriptions ADD CONSTRAINT job_descriptions_job_id_fkey
+    FOREIGN KEY (job_id) REFERENCES jobs(id) ON DELETE CASCADE;
+
+ALTER TABLE job_descriptions DROP COLUMN IF EXISTS source;
+ALTER TABLE jobs DROP COLUMN IF EXISTS source;
@@ -0,0 +1,20 @@
+-- Jobs are identified by the board they came from together with the board's own ID
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS source VARCHAR(50) NOT NULL DEFAULT 'linkedin';
+ALTER TABLE job_descriptions ADD COLUMN IF NOT EXISTS source VARCHAR(50) NOT NULL DEFAULT 'linkedin';
+
+ALTER TABLE job_descriptions DROP CONSTRAINT IF EXISTS job_descriptions_job_id_fkey;
+ALTER TABLE job_descriptions DROP CONSTRAINT IF EXISTS unique_job_id;
+
+ALTER TABLE jobs DROP CONSTRAINT IF EXISTS jobs_pkey;
+ALTER TABLE jobs ADD CONSTRAINT jobs_pkey PRIMARY KEY (source, id);
+
+-- A link only has to be unique within its board, from 001 and 004 it was unique across boards
+ALTER TABLE jobs DROP CONSTRAINT IF EXISTS jobs_job_link_key;
+ALTER TABLE jobs DROP CONSTRAINT IF EXISTS unique_job_link;
+ALTER TABLE jobs ADD CONSTRAINT unique_job_link UNIQUE (source, job_link);
+
+ALTER TABLE job_descriptions ADD CONSTRAINT unique_job_id UNIQUE (source, job_id);
+ALTER TABLE job_descriptions ADD CONSTRAINT job_descriptions_job_fkey
+    FOREIGN KEY (source, job_id) REFERENCES jobs(source, id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_jobs_source ON jobs(source);
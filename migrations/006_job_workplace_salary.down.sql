@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_jobs_remote;
+
+ALTER TABLE jobs DROP COLUMN IF EXISTS salary_period;
+ALTER TABLE jobs DROP COLUMN IF EXISTS salary_currency;
+ALTER TABLE jobs DROP COLUMN IF EXISTS salary_max;
+ALTER TABLE jobs DROP COLUMN IF EXISTS salary_min;
+ALTER TABLE jobs DROP COLUMN IF EXISTS remote;
+ALTER TABLE jobs DROP COLUMN IF EXISTS workplace_type;
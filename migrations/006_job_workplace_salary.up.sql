@@ -0,0 +1,8 @@
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS workplace_type VARCHAR(20);
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS remote BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS salary_min NUMERIC(14, 2);
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS salary_max NUMERIC(14, 2);
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS salary_currency VARCHAR(3);
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS salary_period VARCHAR(10);
+
+CREATE INDEX IF NOT EXISTS idx_jobs_remote ON jobs(remote);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_job_search_matches_query;
+DROP TABLE IF EXISTS job_search_matches;
+DROP TABLE IF EXISTS search_queries;
@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS search_queries (
+    id SERIAL PRIMARY KEY,
+    query_key TEXT NOT NULL UNIQUE,
+    keywords TEXT NOT NULL DEFAULT '',
+    location TEXT NOT NULL DEFAULT '',
+    f_wt VARCHAR(20) NOT NULL DEFAULT '',
+    geo_id VARCHAR(50) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Which search queries listed each job
+CREATE TABLE IF NOT EXISTS job_search_matches (
+    source VARCHAR(50) NOT NULL,
+    job_id BIGINT NOT NULL,
+    search_query_id INTEGER NOT NULL REFERENCES search_queries(id) ON DELETE CASCADE,
+    first_matched_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    last_matched_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (source, job_id, search_query_id),
+    FOREIGN KEY (source, job_id) REFERENCES jobs(source, id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_job_search_matches_query ON job_search_matches(search_query_id);
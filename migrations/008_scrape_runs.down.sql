@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS scrape_run_pending_jobs;
+DROP TABLE IF EXISTS scrape_run_pages;
+DROP TABLE IF EXISTS scrape_runs;
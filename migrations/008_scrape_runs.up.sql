@@ -0,0 +1,29 @@
+CREATE TABLE IF NOT EXISTS scrape_runs (
+    id BIGSERIAL PRIMARY KEY,
+    status VARCHAR(20) NOT NULL DEFAULT 'running',
+    profile JSONB NOT NULL,
+    error TEXT,
+    started_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    finished_at TIMESTAMP WITH TIME ZONE
+);
+
+-- Number of result pages already fetched per source and query
+CREATE TABLE IF NOT EXISTS scrape_run_pages (
+    run_id BIGINT NOT NULL REFERENCES scrape_runs(id) ON DELETE CASCADE,
+    source VARCHAR(50) NOT NULL,
+    query_key TEXT NOT NULL,
+    pages_done INTEGER NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (run_id, source, query_key)
+);
+
+-- Listed jobs whose descriptions have not been saved yet
+CREATE TABLE IF NOT EXISTS scrape_run_pending_jobs (
+    run_id BIGINT NOT NULL REFERENCES scrape_runs(id) ON DELETE CASCADE,
+    source VARCHAR(50) NOT NULL,
+    job_id BIGINT NOT NULL,
+    query_key TEXT NOT NULL,
+    job JSONB NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (run_id, source, job_id)
+);
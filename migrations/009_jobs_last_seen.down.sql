@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_job_descriptions_updated_at;
+DROP INDEX IF EXISTS idx_jobs_last_seen_at;
+
+ALTER TABLE jobs DROP COLUMN IF EXISTS last_seen_at;
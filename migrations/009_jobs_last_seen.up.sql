@@ -0,0 +1,5 @@
+-- When a job was last listed by a search, jobs not seen for a while are likely no longer posted
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS last_seen_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_jobs_last_seen_at ON jobs(last_seen_at);
+CREATE INDEX IF NOT EXISTS idx_job_descriptions_updated_at ON job_descriptions(updated_at);
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_jobs_posted_at;
+
+ALTER TABLE jobs DROP COLUMN IF EXISTS urn;
+ALTER TABLE jobs DROP COLUMN IF EXISTS company_logo;
+ALTER TABLE jobs DROP COLUMN IF EXISTS badges;
+ALTER TABLE jobs DROP COLUMN IF EXISTS salary_insight;
+ALTER TABLE jobs DROP COLUMN IF EXISTS posted_at;
@@ -0,0 +1,8 @@
+-- Details shown on search result cards, posted_at is stored in UTC
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS posted_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS salary_insight TEXT;
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS badges TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS company_logo TEXT;
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS urn VARCHAR(100);
+
+CREATE INDEX IF NOT EXISTS idx_jobs_posted_at ON jobs(posted_at);
@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS idx_job_descriptions_industries;
+DROP INDEX IF EXISTS idx_job_descriptions_job_functions;
+DROP INDEX IF EXISTS idx_job_descriptions_employment_type;
+DROP INDEX IF EXISTS idx_job_descriptions_seniority;
+
+ALTER TABLE job_descriptions DROP COLUMN IF EXISTS industries;
+ALTER TABLE job_descriptions DROP COLUMN IF EXISTS job_functions;
+ALTER TABLE job_descriptions DROP COLUMN IF EXISTS employment_type;
+ALTER TABLE job_descriptions DROP COLUMN IF EXISTS seniority;
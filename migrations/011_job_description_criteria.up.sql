@@ -0,0 +1,10 @@
+-- Criteria normalized from the localized labels of job_criteria
+ALTER TABLE job_descriptions ADD COLUMN IF NOT EXISTS seniority VARCHAR(30);
+ALTER TABLE job_descriptions ADD COLUMN IF NOT EXISTS employment_type VARCHAR(30);
+ALTER TABLE job_descriptions ADD COLUMN IF NOT EXISTS job_functions TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE job_descriptions ADD COLUMN IF NOT EXISTS industries TEXT[] NOT NULL DEFAULT '{}';
+
+CREATE INDEX IF NOT EXISTS idx_job_descriptions_seniority ON job_descriptions(seniority);
+CREATE INDEX IF NOT EXISTS idx_job_descriptions_employment_type ON job_descriptions(employment_type);
+CREATE INDEX IF NOT EXISTS idx_job_descriptions_job_functions ON job_descriptions USING GIN (job_functions);
+CREATE INDEX IF NOT EXISTS idx_job_descriptions_industries ON job_descriptions USING GIN (industries);
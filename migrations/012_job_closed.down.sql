@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_jobs_open_checked_at;
+DROP INDEX IF EXISTS idx_jobs_closed_at;
+
+ALTER TABLE job_descriptions DROP COLUMN IF EXISTS applicant_count;
+ALTER TABLE jobs DROP COLUMN IF EXISTS checked_at;
+ALTER TABLE jobs DROP COLUMN IF EXISTS closed_at;
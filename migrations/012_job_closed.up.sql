@@ -0,0 +1,7 @@
+-- closed_at is set once a posting no longer accepts applications, checked_at when its page was last fetched
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS closed_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS checked_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE job_descriptions ADD COLUMN IF NOT EXISTS applicant_count INT;
+
+CREATE INDEX IF NOT EXISTS idx_jobs_closed_at ON jobs(closed_at);
+CREATE INDEX IF NOT EXISTS idx_jobs_open_checked_at ON jobs(checked_at) WHERE closed_at IS NULL;
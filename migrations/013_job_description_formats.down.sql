@@ -0,0 +1,2 @@
+ALTER TABLE job_descriptions DROP COLUMN IF EXISTS description_html;
+ALTER TABLE job_descriptions DROP COLUMN IF EXISTS description_markdown;
@@ -0,0 +1,3 @@
+-- The description as Markdown and as sanitized HTML next to the plain text used in prompts
+ALTER TABLE job_descriptions ADD COLUMN IF NOT EXISTS description_markdown TEXT;
+ALTER TABLE job_descriptions ADD COLUMN IF NOT EXISTS description_html TEXT;
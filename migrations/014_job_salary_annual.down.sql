@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_jobs_salary_annual;
+
+ALTER TABLE jobs DROP COLUMN IF EXISTS salary_annual_currency;
+ALTER TABLE jobs DROP COLUMN IF EXISTS salary_annual_max;
+ALTER TABLE jobs DROP COLUMN IF EXISTS salary_annual_min;
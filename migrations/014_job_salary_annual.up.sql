@@ -0,0 +1,6 @@
+-- Salary range converted to a full year and to the base currency of the exchange rates, for filtering and sorting
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS salary_annual_min NUMERIC(14, 2);
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS salary_annual_max NUMERIC(14, 2);
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS salary_annual_currency VARCHAR(3);
+
+CREATE INDEX IF NOT EXISTS idx_jobs_salary_annual ON jobs(salary_annual_currency, salary_annual_max);
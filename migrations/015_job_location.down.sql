@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_jobs_workplace_type;
+DROP INDEX IF EXISTS idx_jobs_country_region_city;
+
+ALTER TABLE jobs DROP COLUMN IF EXISTS city;
+ALTER TABLE jobs DROP COLUMN IF EXISTS region;
+ALTER TABLE jobs DROP COLUMN IF EXISTS country_code;
+ALTER TABLE jobs DROP COLUMN IF EXISTS work_type_filter;
@@ -0,0 +1,8 @@
+-- Location resolved against the gazetteer and the f_WT filter of the search that listed the job
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS work_type_filter VARCHAR(10);
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS country_code VARCHAR(2);
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS region VARCHAR(100);
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS city VARCHAR(100);
+
+CREATE INDEX IF NOT EXISTS idx_jobs_country_region_city ON jobs(country_code, region, city);
+CREATE INDEX IF NOT EXISTS idx_jobs_workplace_type ON jobs(workplace_type);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_job_descriptions_language_requirements;
+DROP INDEX IF EXISTS idx_job_descriptions_language;
+
+ALTER TABLE job_descriptions DROP COLUMN IF EXISTS language_requirements;
+ALTER TABLE job_descriptions DROP COLUMN IF EXISTS description_language;
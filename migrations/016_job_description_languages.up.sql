@@ -0,0 +1,8 @@
+-- Language the description is written in and the spoken languages it asks for, e.g.
+-- [{"language": "ja", "level": "business", "required": true, "evidence": "JLPT N2"}].
+-- language_requirements stays NULL for descriptions saved before languages were detected.
+ALTER TABLE job_descriptions ADD COLUMN IF NOT EXISTS description_language VARCHAR(5);
+ALTER TABLE job_descriptions ADD COLUMN IF NOT EXISTS language_requirements JSONB;
+
+CREATE INDEX IF NOT EXISTS idx_job_descriptions_language ON job_descriptions(description_language);
+CREATE INDEX IF NOT EXISTS idx_job_descriptions_language_requirements ON job_descriptions USING GIN (language_requirements);
@@ -0,0 +1,3 @@
+-- Every filter of the query as in search profiles, e.g. {"keywords": "Go", "f_E": [2, 3], "f_AL": true}.
+-- The f_E, f_JT, f_C, f_AL and f_EA filters are part of query_key but have no column of their own.
+ALTER TABLE search_queries ADD COLUMN IF NOT EXISTS filters JSONB NOT NULL DEFAULT '{}';